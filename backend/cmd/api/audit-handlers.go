@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/BradPreston/go-movies/backend/models"
+)
+
+// getAuditLog lists audit entries, newest first. It can be filtered by
+// actor_id, action, entity, entity_id (or movie_id as a shorthand for movie
+// entries) and a from/to time range given as RFC 3339 times or dates.
+func (app *application) getAuditLog(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	var filter models.AuditFilter
+	var err error
+
+	filter.Action = qs.Get("action")
+	filter.Entity = qs.Get("entity")
+
+	ints := map[string]*int{
+		"actor_id":  &filter.ActorID,
+		"entity_id": &filter.EntityID,
+	}
+	for name, dest := range ints {
+		if v := qs.Get(name); v != "" {
+			if *dest, err = strconv.Atoi(v); err != nil {
+				app.errorJSON(w, errors.New(name+" must be a number"))
+				return
+			}
+		}
+	}
+
+	if v := qs.Get("movie_id"); v != "" {
+		if filter.EntityID, err = strconv.Atoi(v); err != nil {
+			app.errorJSON(w, errors.New("movie_id must be a number"))
+			return
+		}
+		filter.Entity = "movie"
+	}
+
+	times := map[string]*time.Time{
+		"from": &filter.From,
+		"to":   &filter.To,
+	}
+	for name, dest := range times {
+		if v := qs.Get(name); v != "" {
+			if *dest, err = parseTime(v); err != nil {
+				app.errorJSON(w, errors.New(name+" must be an RFC 3339 time or a YYYY-MM-DD date"))
+				return
+			}
+		}
+	}
+
+	if filter.Page, err = app.readPage(r); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	entries, next, err := app.models.DB.AuditLog(r.Context(), filter)
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, entries, "audit", pageMeta(next)); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
+
+// parseTime accepts an RFC 3339 time or a plain date
+func parseTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/BradPreston/go-movies/backend/models"
+)
+
+type contextKey string
+
+const (
+	userIDContextKey    contextKey = "userID"
+	tokenContextKey     contextKey = "token"
+	requestIDContextKey contextKey = "requestID"
+	connContextKey      contextKey = "conn"
+)
+
+// contextSetUserID returns a copy of the request carrying the authenticated user ID
+func contextSetUserID(r *http.Request, userID int) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), userIDContextKey, userID))
+}
+
+// contextGetUserID returns the authenticated user ID, or 0 for anonymous requests
+func contextGetUserID(r *http.Request) int {
+	id, _ := r.Context().Value(userIDContextKey).(int)
+	return id
+}
+
+// contextSetToken returns a copy of the request carrying its access token
+// and the ID of the user it was issued to
+func contextSetToken(r *http.Request, token accessToken) *http.Request {
+	r = contextSetUserID(r, token.UserID)
+	return r.WithContext(context.WithValue(r.Context(), tokenContextKey, token))
+}
+
+// contextGetToken returns the access token of the request, if it had a valid one
+func contextGetToken(r *http.Request) (accessToken, bool) {
+	token, ok := r.Context().Value(tokenContextKey).(accessToken)
+	return token, ok
+}
+
+// contextGetRole returns the role of the authenticated user, or "" for anonymous requests
+func contextGetRole(r *http.Request) string {
+	token, _ := contextGetToken(r)
+	return token.Role
+}
+
+// contextSetRequestID returns a copy of the request carrying its request ID
+func contextSetRequestID(r *http.Request, requestID string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), requestIDContextKey, requestID))
+}
+
+// contextGetRequestID returns the ID assigned to the request by the requestID middleware
+func contextGetRequestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDContextKey).(string)
+	return id
+}
+
+// contextSetConn returns a copy of a connection's base context carrying the
+// connection, for the server's ConnContext hook
+func contextSetConn(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey, c)
+}
+
+// contextGetConn returns the connection the request arrived on
+func contextGetConn(r *http.Request) (net.Conn, bool) {
+	c, ok := r.Context().Value(connContextKey).(net.Conn)
+	return c, ok
+}
+
+// actor returns who is making the request, for the audit log
+func (app *application) actor(r *http.Request) models.Actor {
+	return models.Actor{
+		UserID:    contextGetUserID(r),
+		RequestID: contextGetRequestID(r),
+	}
+}
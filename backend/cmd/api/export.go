@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BradPreston/go-movies/backend/models"
+)
+
+// exportFlushRows is the number of rows written between flushes
+const exportFlushRows = 100
+
+// exportContentTypes maps the export formats to their content types
+var exportContentTypes = map[string]string{
+	"csv":    "text/csv; charset=utf-8",
+	"json":   "application/json",
+	"ndjson": "application/x-ndjson",
+}
+
+// exportColumns is the header row of a CSV export. genre_ids uses the same
+// format as imports, so an export can be imported again.
+var exportColumns = []string{
+	"id", "title", "description", "year", "release_date", "runtime", "rating", "mpaa_rating",
+	"genre_ids", "genres", "poster", "created_at", "updated_at", "version",
+}
+
+// exportMovies streams the catalog as CSV, a JSON array or NDJSON. It takes
+// the same filters and sort as the movie list but is not paged.
+func (app *application) exportMovies(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if _, ok := exportContentTypes[format]; !ok {
+		app.errorJSON(w, errors.New("format must be csv, json or ndjson"))
+		return
+	}
+
+	filter, err := app.readUnpagedMovieFilter(r)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	e := &exporter{w: w, format: format}
+	// the server's write timeout would cut a long export off, so the
+	// deadline is moved forward as each batch of rows is sent instead
+	e.conn, _ = contextGetConn(r)
+	err = app.models.DB.ExportMovies(r.Context(), filter, e.write)
+	if err == nil {
+		err = e.finish()
+	}
+	if err != nil {
+		if !e.started {
+			app.storeErrorJSON(w, err)
+			return
+		}
+		// the status line has been sent, so the truncated body is all
+		// the client gets
+		app.logger.Println("export:", models.Cause(err))
+	}
+}
+
+// exporter writes movies to the response one at a time. The response is
+// only started by the first movie, so that an error before it can still be
+// reported with a status code.
+type exporter struct {
+	w       http.ResponseWriter
+	conn    net.Conn
+	format  string
+	csv     *csv.Writer
+	rows    int
+	started bool
+}
+
+// start writes the headers and the opening of the body
+func (e *exporter) start() error {
+	e.started = true
+	e.extendDeadline()
+
+	e.w.Header().Set("Content-Type", exportContentTypes[e.format])
+	e.w.Header().Set("Content-Disposition", `attachment; filename="movies.`+e.format+`"`)
+	e.w.WriteHeader(http.StatusOK)
+
+	switch e.format {
+	case "csv":
+		e.csv = csv.NewWriter(e.w)
+		return e.csv.Write(exportColumns)
+	case "json":
+		_, err := io.WriteString(e.w, "[")
+		return err
+	}
+	return nil
+}
+
+// write adds one movie to the response
+func (e *exporter) write(movie *models.Movie) error {
+	if !e.started {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	switch e.format {
+	case "csv":
+		err = e.csv.Write(exportRecord(movie))
+	case "json":
+		if e.rows > 0 {
+			if _, err = io.WriteString(e.w, ","); err != nil {
+				return err
+			}
+		}
+		err = json.NewEncoder(e.w).Encode(movie)
+	case "ndjson":
+		err = json.NewEncoder(e.w).Encode(movie)
+	}
+	if err != nil {
+		return err
+	}
+
+	e.rows++
+	if e.rows%exportFlushRows == 0 {
+		return e.flush()
+	}
+	return nil
+}
+
+// finish closes the body, starting it first if no movie matched
+func (e *exporter) finish() error {
+	if !e.started {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+
+	if e.format == "json" {
+		if _, err := io.WriteString(e.w, "]\n"); err != nil {
+			return err
+		}
+	}
+
+	return e.flush()
+}
+
+// flush sends everything written so far to the client
+func (e *exporter) flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if f, ok := e.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	e.extendDeadline()
+	return nil
+}
+
+// extendDeadline gives the client writeTimeout to take the next batch of
+// rows, so that a stalled client still times out
+func (e *exporter) extendDeadline() {
+	if e.conn != nil {
+		e.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	}
+}
+
+// exportRecord returns the CSV record of a movie
+func exportRecord(movie *models.Movie) []string {
+	ids := movie.GenreIDs()
+	genreIDs := make([]string, len(ids))
+	genres := make([]string, len(ids))
+	for i, id := range ids {
+		genreIDs[i] = strconv.Itoa(id)
+		genres[i] = movie.MovieGenre[id]
+	}
+
+	return []string{
+		strconv.Itoa(movie.ID),
+		movie.Title,
+		movie.Description,
+		strconv.Itoa(movie.Year),
+		movie.ReleaseDate.Format("2006-01-02"),
+		strconv.Itoa(movie.Runtime),
+		strconv.Itoa(movie.Rating),
+		movie.MPAARating,
+		strings.Join(genreIDs, ";"),
+		strings.Join(genres, ";"),
+		movie.Poster,
+		movie.CreatedAt.Format(time.RFC3339),
+		movie.UpdatedAt.Format(time.RFC3339),
+		strconv.Itoa(movie.Version),
+	}
+}
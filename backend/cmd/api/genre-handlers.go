@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/BradPreston/go-movies/backend/models"
+	"github.com/julienschmidt/httprouter"
+)
+
+type GenrePayload struct {
+	GenreName string `json:"genre_name"`
+}
+
+// readGenrePayload decodes and validates a genre from the request body
+func (app *application) readGenrePayload(r *http.Request) (string, error) {
+	var payload GenrePayload
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return "", err
+	}
+
+	name := strings.TrimSpace(payload.GenreName)
+	if name == "" {
+		return "", errors.New("genre_name is required")
+	}
+	if len(name) > 255 {
+		return "", errors.New("genre_name must be at most 255 characters")
+	}
+
+	return name, nil
+}
+
+// createGenre adds a genre
+func (app *application) createGenre(w http.ResponseWriter, r *http.Request) {
+	name, err := app.readGenrePayload(r)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	genre, err := app.models.DB.InsertGenre(r.Context(), name, app.actor(r))
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	if err = app.writeJSON(w, http.StatusCreated, genre, "genre"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
+
+// renameGenre changes the name of a genre
+func (app *application) renameGenre(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	name, err := app.readGenrePayload(r)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	genre := models.Genre{ID: id, GenreName: name}
+	if err = app.models.DB.UpdateGenre(r.Context(), genre, app.actor(r)); err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, genre, "genre"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
+
+// deleteGenre deletes a genre that is not assigned to any movie
+func (app *application) deleteGenre(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if err = app.models.DB.DeleteGenre(r.Context(), id, app.actor(r)); err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	ok := jsonResponse{
+		OK: true,
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, ok, "response"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
@@ -110,9 +110,9 @@ var movieType = graphql.NewObject(
 )
 
 func (app *application) moviesGraphQL(w http.ResponseWriter, r *http.Request) {
-	m, err := app.models.DB.All()
+	m, _, err := app.models.DB.All(r.Context(), models.MovieFilter{})
 	if err != nil {
-		app.errorJSON(w, err)
+		app.storeErrorJSON(w, err)
 		return
 	}
 	movies = m
@@ -136,7 +136,7 @@ func (app *application) moviesGraphQL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	params := graphql.Params{Schema: schema, RequestString: query}
+	params := graphql.Params{Schema: schema, RequestString: query, Context: r.Context()}
 	resp := graphql.Do(params)
 	if len(resp.Errors) > 0 {
 		app.errorJSON(w, fmt.Errorf("failed: %+v", resp.Errors))
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BradPreston/go-movies/backend/models"
+)
+
+// maxImportBytes limits the size of an uploaded import file
+const maxImportBytes = 32 << 20
+
+// importMovies creates, or updates, movies in bulk from a CSV or JSON
+// upload. The format comes from the format parameter or the Content-Type;
+// atomic=true imports every row or none and upsert=true updates movies with
+// the same title and year instead of duplicating them.
+func (app *application) importMovies(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	format := qs.Get("format")
+	if format == "" {
+		format = importFormat(r.Header.Get("Content-Type"))
+	}
+
+	var opts models.ImportOptions
+	for name, value := range map[string]*bool{"atomic": &opts.Atomic, "upsert": &opts.Upsert} {
+		if s := qs.Get(name); s != "" {
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				app.errorJSON(w, errors.New(name+" must be true or false"))
+				return
+			}
+			*value = b
+		}
+	}
+
+	result, err := app.runImport(r.Context(), app.actor(r), http.MaxBytesReader(w, r.Body, maxImportBytes), format, opts)
+	if err != nil {
+		if errors.Is(err, errImportFormat) {
+			app.errorJSON(w, err)
+			return
+		}
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	status := http.StatusOK
+	if opts.Atomic && result.Failed > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+
+	if err = app.writeJSON(w, status, result, "import"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
+
+// importFile runs the import subcommand: import [-format csv|json] [-atomic] [-upsert] file
+func (app *application) importFile(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := fs.String("format", "", "File format (csv|json), by default taken from the file extension")
+	var opts models.ImportOptions
+	fs.BoolVar(&opts.Atomic, "atomic", false, "Import every row or none of them")
+	fs.BoolVar(&opts.Upsert, "upsert", false, "Update movies with the same title and year instead of duplicating them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: import [-format csv|json] [-atomic] [-upsert] file")
+	}
+
+	path := fs.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	result, err := app.runImport(context.Background(), models.Actor{}, f, *format, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range result.Errors {
+		app.logger.Printf("row %d: %s", e.Row, e.Error)
+	}
+	app.logger.Printf("inserted %d, updated %d, failed %d", result.Inserted, result.Updated, result.Failed)
+
+	if result.Failed > 0 {
+		return fmt.Errorf("%d rows could not be imported", result.Failed)
+	}
+	return nil
+}
+
+// runImport reads and validates movies and imports the valid ones. Rows that
+// fail validation are reported alongside the rows rejected by the store; in
+// atomic mode they stop the import before the store is called.
+func (app *application) runImport(ctx context.Context, actor models.Actor, body io.Reader, format string, opts models.ImportOptions) (*models.ImportResult, error) {
+	payloads, err := readImport(body, format)
+	if err != nil {
+		return nil, err
+	}
+
+	invalid := &models.ImportResult{}
+	var rows []models.ImportRow
+	now := time.Now()
+	for i, payload := range payloads {
+		movie, err := payload.movie()
+		if err != nil {
+			invalid.Failed++
+			invalid.Errors = append(invalid.Errors, models.ImportError{Row: i + 1, Error: err.Error()})
+			continue
+		}
+		movie.CreatedAt, movie.UpdatedAt = now, now
+		rows = append(rows, models.ImportRow{Row: i + 1, Movie: movie})
+	}
+	if opts.Atomic && invalid.Failed > 0 {
+		return invalid, nil
+	}
+
+	result, err := app.models.DB.ImportMovies(ctx, rows, opts, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Failed += invalid.Failed
+	result.Errors = append(result.Errors, invalid.Errors...)
+	sort.Slice(result.Errors, func(i, j int) bool {
+		return result.Errors[i].Row < result.Errors[j].Row
+	})
+
+	return result, nil
+}
+
+// errImportFormat is returned for an unknown or malformed import file
+var errImportFormat = errors.New("import must be a CSV file with a header row or a JSON array of movies")
+
+// importFormat returns the import format matching a Content-Type
+func importFormat(contentType string) string {
+	switch {
+	case strings.HasPrefix(contentType, "text/csv"):
+		return "csv"
+	case strings.HasPrefix(contentType, "application/json"):
+		return "json"
+	}
+	return ""
+}
+
+// readImport decodes movies in the MoviePayload shape from a JSON array or
+// from CSV whose header row names the MoviePayload fields. In CSV, genre_ids
+// holds genre IDs separated by semicolons or spaces.
+func readImport(body io.Reader, format string) ([]MoviePayload, error) {
+	switch format {
+	case "json":
+		var payloads []MoviePayload
+		if err := json.NewDecoder(body).Decode(&payloads); err != nil {
+			return nil, fmt.Errorf("%w: %v", errImportFormat, err)
+		}
+		return payloads, nil
+	case "csv":
+		return readImportCSV(body)
+	}
+
+	return nil, errImportFormat
+}
+
+// readImportCSV decodes movies from CSV with a header row
+func readImportCSV(body io.Reader) ([]MoviePayload, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errImportFormat, err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"title", "release_date"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%w: missing %s column", errImportFormat, required)
+		}
+	}
+
+	var payloads []MoviePayload
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errImportFormat, err)
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		payload := MoviePayload{
+			Title:       field("title"),
+			Description: field("description"),
+			Year:        field("year"),
+			ReleaseDate: field("release_date"),
+			Runtime:     field("runtime"),
+			Rating:      field("rating"),
+			MPAARating:  field("mpaa_rating"),
+		}
+		for _, id := range strings.FieldsFunc(field("genre_ids"), func(r rune) bool { return r == ';' || r == ' ' }) {
+			n, err := strconv.Atoi(id)
+			if err != nil {
+				// keep the row so that it is reported as invalid
+				n = -1
+			}
+			payload.GenreIDs = append(payload.GenreIDs, n)
+		}
+
+		payloads = append(payloads, payload)
+	}
+
+	return payloads, nil
+}
+
+// movie validates an imported payload and converts it to a movie
+func (p MoviePayload) movie() (models.Movie, error) {
+	var movie models.Movie
+	var err error
+
+	movie.Title = strings.TrimSpace(p.Title)
+	movie.ReleaseDate, err = time.Parse("2006-01-02", p.ReleaseDate)
+	if err != nil {
+		return movie, errors.New("release_date must be a YYYY-MM-DD date")
+	}
+	movie.Year = movie.ReleaseDate.Year()
+
+	if movie.Runtime, err = strconv.Atoi(p.Runtime); err != nil {
+		return movie, errors.New("runtime must be a positive number")
+	}
+	if movie.Rating, err = strconv.Atoi(p.Rating); err != nil {
+		return movie, errors.New("rating must be a number from 0 to 5")
+	}
+
+	movie.Description = p.Description
+	movie.MPAARating = p.MPAARating
+
+	// leave the genres of an upserted movie alone unless genre_ids is given
+	if p.GenreIDs != nil {
+		movie.MovieGenre = make(map[int]string, len(p.GenreIDs))
+		for _, id := range p.GenreIDs {
+			movie.MovieGenre[id] = ""
+		}
+	}
+
+	return movie, movie.Validate()
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/BradPreston/go-movies/backend/models"
+)
+
+// TestImportValidatesLikeEdit checks that import rejects the movies that
+// editMovie rejects, since both validate with models.Movie.Validate
+func TestImportValidatesLikeEdit(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload MoviePayload
+		valid   bool
+	}{
+		{"valid", MoviePayload{Title: "Heat", ReleaseDate: "1995-12-15", Runtime: "170", Rating: "5"}, true},
+		{"rating above 5", MoviePayload{Title: "Heat", ReleaseDate: "1995-12-15", Runtime: "170", Rating: "9"}, false},
+		{"negative runtime", MoviePayload{Title: "Heat", ReleaseDate: "1995-12-15", Runtime: "-1", Rating: "5"}, false},
+		{"no title", MoviePayload{ReleaseDate: "1995-12-15", Runtime: "170", Rating: "5"}, false},
+		{"invalid genre ID", MoviePayload{Title: "Heat", ReleaseDate: "1995-12-15", Runtime: "170", Rating: "5", GenreIDs: []int{-1}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.payload.movie()
+			if tt.valid && err != nil {
+				t.Errorf("got %v, want no error", err)
+			}
+			if !tt.valid && !errors.Is(err, models.ErrValidation) {
+				t.Errorf("got %v, want ErrValidation", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/BradPreston/go-movies/backend/models"
+	"github.com/julienschmidt/httprouter"
+)
+
+// getList lists the movies on one of the authenticated user's lists, most
+// recently added first
+func (app *application) getList(list string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page, err := app.readPage(r)
+		if err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+
+		movies, next, err := app.models.DB.ListMovies(r.Context(), contextGetUserID(r), list, page)
+		if err != nil {
+			app.storeErrorJSON(w, err)
+			return
+		}
+
+		if err = app.markWatchlist(r, movies...); err != nil {
+			app.storeErrorJSON(w, err)
+			return
+		}
+
+		if err = app.writeJSON(w, http.StatusOK, movies, "movies", pageMeta(next)); err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+	}
+}
+
+// addToList puts a movie on one of the authenticated user's lists
+func (app *application) addToList(list string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		app.changeList(w, r, list, app.models.DB.AddToList)
+	}
+}
+
+// removeFromList takes a movie off one of the authenticated user's lists
+func (app *application) removeFromList(list string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		app.changeList(w, r, list, app.models.DB.RemoveFromList)
+	}
+}
+
+// changeList applies change to the movie ID parameter on one of the
+// authenticated user's lists
+func (app *application) changeList(w http.ResponseWriter, r *http.Request, list string, change func(ctx context.Context, userID int, list string, movieID int) error) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	movieID, err := strconv.Atoi(params.ByName("id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if err = change(r.Context(), contextGetUserID(r), list, movieID); err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	ok := jsonResponse{
+		OK: true,
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, ok, "response"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
+
+// markWatchlist sets the in_watchlist flag of movies returned to an
+// authenticated user. Anonymous responses are left without it.
+func (app *application) markWatchlist(r *http.Request, movies ...*models.Movie) error {
+	userID := contextGetUserID(r)
+	if userID == 0 || len(movies) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(movies))
+	for i, movie := range movies {
+		ids[i] = movie.ID
+	}
+
+	found, err := app.models.DB.InWatchlist(r.Context(), userID, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, movie := range movies {
+		in := found[movie.ID]
+		movie.InWatchlist = &in
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Mailer sends plain text emails
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// smtpMailer sends emails through an SMTP server
+type smtpMailer struct {
+	addr   string
+	auth   smtp.Auth
+	sender string
+}
+
+// newSMTPMailer returns a mailer for the SMTP server at host:port. Without a
+// username it sends without authenticating.
+func newSMTPMailer(host string, port int, username, password, sender string) *smtpMailer {
+	m := &smtpMailer{
+		addr:   fmt.Sprintf("%s:%d", host, port),
+		sender: sender,
+	}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m
+}
+
+// Send delivers an email. net/smtp cannot be cancelled, so ctx is only
+// checked before connecting.
+func (m *smtpMailer) Send(ctx context.Context, to, subject, body string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return smtp.SendMail(m.addr, m.auth, m.sender, []string{to}, message(m.sender, to, subject, body))
+}
+
+// logMailer writes emails to the log, and to a file per email when dir is
+// set, instead of sending them. It is meant for development and tests.
+type logMailer struct {
+	logger *log.Logger
+	dir    string
+	sender string
+}
+
+// Send logs an email and writes it to a .eml file in the mail directory
+func (m *logMailer) Send(ctx context.Context, to, subject, body string) error {
+	msg := message(m.sender, to, subject, body)
+
+	if m.dir == "" {
+		m.logger.Printf("mail to %s:\n%s", to, msg)
+		return nil
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405.000000000"), strings.NewReplacer("@", "_at_", "/", "_").Replace(to))
+	path := filepath.Join(m.dir, name)
+	if err := os.WriteFile(path, msg, 0o600); err != nil {
+		return err
+	}
+
+	m.logger.Printf("mail to %s written to %s", to, path)
+	return nil
+}
+
+// message formats a plain text email
+func message(from, to, subject, body string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// sendMail sends an email in the background so that the response does not
+// wait for, or reveal, the delivery. Failures are logged.
+func (app *application) sendMail(to, subject, body string) {
+	app.background(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), backgroundTimeout)
+		defer cancel()
+
+		if err := app.mailer.Send(ctx, to, subject, body); err != nil {
+			app.logger.Println("mail:", err)
+		}
+	})
+}
+
+// backgroundTimeout bounds the work of a background job
+const backgroundTimeout = 30 * time.Second
+
+// background runs fn in a goroutine, logging a panic instead of crashing
+func (app *application) background(fn func()) {
+	app.wg.Add(1)
+	go func() {
+		defer app.wg.Done()
+		defer func() {
+			if err := recover(); err != nil {
+				app.logger.Println("background:", err)
+			}
+		}()
+
+		fn()
+	}()
+}
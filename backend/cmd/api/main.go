@@ -8,6 +8,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/BradPreston/go-movies/backend/models"
@@ -17,14 +18,36 @@ import (
 
 const version = "1.0.0"
 
+// writeTimeout is how long a handler has to write its response. Exports,
+// which can take longer, extend it as they go.
+const writeTimeout = 30 * time.Second
+
 type config struct {
-	port int
-	env  string
-	db   struct {
-		dsn string
+	port  int
+	env   string
+	store string
+	db    struct {
+		dsn     string
+		timeout time.Duration
 	}
 	jwt struct {
-		secret string
+		secret     string
+		accessTTL  time.Duration
+		refreshTTL time.Duration
+	}
+	trash struct {
+		retention time.Duration
+	}
+	smtp struct {
+		host     string
+		port     int
+		username string
+		password string
+		sender   string
+	}
+	mail struct {
+		dir      string
+		resetURL string
 	}
 }
 
@@ -38,6 +61,8 @@ type application struct {
 	config config
 	logger *log.Logger
 	models models.Models
+	mailer Mailer
+	wg     sync.WaitGroup // background work, see background
 }
 
 func main() {
@@ -51,20 +76,87 @@ func main() {
 
 	flag.IntVar(&cfg.port, "port", 8080, "Server port to listen on")
 	flag.StringVar(&cfg.env, "env", "development", "Application environment (development|production)")
+	flag.StringVar(&cfg.store, "store", "postgres", "Movie store backend (postgres|memory)")
 	flag.StringVar(&cfg.db.dsn, "dsn", fmt.Sprintf("postgres://%s:%s@localhost/go_movies?sslmode=disable", env["USER"], env["PASSWORD"]), "Postgres connection string")
+	flag.DurationVar(&cfg.db.timeout, "db-timeout", models.DefaultTimeout, "Maximum duration of a single database query")
 	flag.StringVar(&cfg.jwt.secret, "jwt-secret", env["JWT_TOKEN"], "secret")
+	flag.DurationVar(&cfg.jwt.accessTTL, "access-ttl", 15*time.Minute, "How long an access token is valid")
+	flag.DurationVar(&cfg.jwt.refreshTTL, "refresh-ttl", 30*24*time.Hour, "How long a refresh token is valid")
+	flag.StringVar(&cfg.smtp.host, "smtp-host", env["SMTP_HOST"], "SMTP server host; without one, emails are logged instead of sent")
+	flag.IntVar(&cfg.smtp.port, "smtp-port", 587, "SMTP server port")
+	flag.StringVar(&cfg.smtp.username, "smtp-username", env["SMTP_USERNAME"], "SMTP username")
+	flag.StringVar(&cfg.smtp.password, "smtp-password", env["SMTP_PASSWORD"], "SMTP password")
+	flag.StringVar(&cfg.smtp.sender, "smtp-sender", "Go Movies <no-reply@go-movies.local>", "Sender of emails")
+	flag.StringVar(&cfg.mail.dir, "mail-dir", "", "Directory the logging mailer writes emails to")
+	flag.StringVar(&cfg.mail.resetURL, "reset-url", "http://localhost:3000/reset-password?token=", "Password reset link, to which the token is appended")
+	flag.DurationVar(&cfg.trash.retention, "trash-retention", 30*24*time.Hour, "How long deleted movies stay in the trash before they can be purged")
 	flag.Parse()
 
-	db, err := openDB(cfg)
-	if err != nil {
-		logger.Fatal(err)
-	}
-	defer db.Close()
-
 	app := &application{
 		config: cfg,
 		logger: logger,
-		models: models.NewModels(db),
+	}
+
+	if cfg.smtp.host != "" {
+		app.mailer = newSMTPMailer(cfg.smtp.host, cfg.smtp.port, cfg.smtp.username, cfg.smtp.password, cfg.smtp.sender)
+	} else {
+		app.mailer = &logMailer{logger: logger, dir: cfg.mail.dir, sender: cfg.smtp.sender}
+	}
+
+	if flag.Arg(0) == "migrate" {
+		db, err := openDB(cfg)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		defer db.Close()
+
+		if err = app.migrate(db, flag.Args()[1:]); err != nil {
+			logger.Fatal(err)
+		}
+		return
+	}
+
+	if flag.Arg(0) == "import" {
+		db, err := openDB(cfg)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		defer db.Close()
+
+		app.models = models.NewModels(db, cfg.db.timeout)
+		if err = app.importFile(flag.Args()[1:]); err != nil {
+			logger.Fatal(err)
+		}
+		return
+	}
+
+	if flag.Arg(0) == "role" {
+		db, err := openDB(cfg)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		defer db.Close()
+
+		app.models = models.NewModels(db, cfg.db.timeout)
+		if err = app.setRole(flag.Args()[1:]); err != nil {
+			logger.Fatal(err)
+		}
+		return
+	}
+
+	switch cfg.store {
+	case "postgres":
+		db, err := openDB(cfg)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		defer db.Close()
+
+		app.models = models.NewModels(db, cfg.db.timeout)
+	case "memory":
+		app.models = models.NewMemoryModels()
+	default:
+		logger.Fatalf("unknown store %q", cfg.store)
 	}
 
 	fmt.Println("Running")
@@ -74,7 +166,8 @@ func main() {
 		Handler:      app.routes(),
 		IdleTimeout:  time.Minute,
 		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 30 * time.Second,
+		WriteTimeout: writeTimeout,
+		ConnContext:  contextSetConn,
 	}
 
 	logger.Println("Starting server on port", cfg.port)
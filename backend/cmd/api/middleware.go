@@ -1,13 +1,17 @@
 package main
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
-	"log"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/BradPreston/go-movies/backend/models"
+	"github.com/justinas/alice"
 	"github.com/pascaldekloe/jwt"
 )
 
@@ -15,58 +19,155 @@ import (
 func (app *application) enableCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type,Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type,Authorization,If-Match,X-Request-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag,Location,X-Request-ID")
 		next.ServeHTTP(w, r)
 	})
 }
 
+// requestID tags every request with an ID, reusing a sane X-Request-ID sent
+// by the client, and echoes it in the response
+func (app *application) requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if id == "" || len(id) > 100 {
+			b := make([]byte, 16)
+			if _, err := rand.Read(b); err != nil {
+				app.errorJSON(w, err, http.StatusInternalServerError)
+				return
+			}
+			id = hex.EncodeToString(b)
+		}
+
+		w.Header().Set("X-Request-ID", id)
+		next.ServeHTTP(w, contextSetRequestID(r, id))
+	})
+}
+
+// errAuthHeader is returned for a missing or malformed Authorization header
+var errAuthHeader = errors.New("invalid auth header")
+
 // checkToken verifies that the token is valid
 func (app *application) checkToken(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add("vary", "Authorization")
-		authHeader := r.Header.Get("Authorization")
 
-		headerParts := strings.Split(authHeader, " ")
-		if len(headerParts) != 2 {
-			app.errorJSON(w, errors.New("invalid auth header"))
+		token, err := app.authenticate(r)
+		if err != nil {
+			if errors.Is(err, errAuthHeader) {
+				app.errorJSON(w, err)
+				return
+			}
+			app.errorJSON(w, err, http.StatusForbidden)
 			return
 		}
 
-		if headerParts[0] != "Bearer" {
-			app.errorJSON(w, errors.New("unauthorized - no Bearer"))
-		}
-
-		token := headerParts[1]
-
-		claims, err := jwt.HMACCheck([]byte(token), []byte(app.config.jwt.secret))
+		denied, err := app.tokenDenied(r, token)
 		if err != nil {
-			app.errorJSON(w, errors.New("unathorized - failed hmac check"), http.StatusForbidden)
+			app.storeErrorJSON(w, err)
 			return
 		}
-
-		if !claims.Valid(time.Now()) {
-			app.errorJSON(w, errors.New("unathorized - token is expired"), http.StatusForbidden)
+		if denied {
+			app.errorJSON(w, errors.New("unathorized - token has been revoked"), http.StatusForbidden)
 			return
 		}
 
-		if !claims.AcceptAudience("mydomain.com") {
-			app.errorJSON(w, errors.New("unathorized - invalid audience"), http.StatusForbidden)
-			return
-		}
+		next.ServeHTTP(w, contextSetToken(r, token))
+	})
+}
 
-		if claims.Issuer != "mydomain.com" {
-			app.errorJSON(w, errors.New("unathorized - invalid issuer"), http.StatusForbidden)
-			return
-		}
+// requireRole only lets requests through whose token grants at least role.
+// It must come after checkToken.
+func (app *application) requireRole(role string) alice.Constructor {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !models.HasRole(contextGetRole(r), role) {
+				app.errorJSON(w, fmt.Errorf("forbidden - this action requires the %s role", role), http.StatusForbidden)
+				return
+			}
 
-		userID, err := strconv.ParseInt(claims.Subject, 10, 64)
-		if err != nil {
-			app.errorJSON(w, errors.New("could not get user ID from token"), http.StatusForbidden)
-			return
-		}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
 
-		log.Println("valid user:", userID)
+// optionalToken identifies the user of requests that carry a valid token
+// and lets every other request through anonymously
+func (app *application) optionalToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("vary", "Authorization")
+
+		if r.Header.Get("Authorization") != "" {
+			if token, err := app.authenticate(r); err == nil {
+				if denied, err := app.tokenDenied(r, token); err == nil && !denied {
+					r = contextSetToken(r, token)
+				}
+			}
+		}
 
 		next.ServeHTTP(w, r)
 	})
 }
+
+// accessToken is a valid access token and the user it was issued to
+type accessToken struct {
+	ID      string // JWT ID, empty in tokens issued before logout existed
+	UserID  int
+	Role    string
+	Expires time.Time
+}
+
+// authenticate checks the bearer token of a request and returns the user it was
+// issued to. Tokens issued before roles existed grant the viewer role.
+func (app *application) authenticate(r *http.Request) (accessToken, error) {
+	headerParts := strings.Split(r.Header.Get("Authorization"), " ")
+	if len(headerParts) != 2 {
+		return accessToken{}, errAuthHeader
+	}
+
+	if headerParts[0] != "Bearer" {
+		return accessToken{}, fmt.Errorf("%w: unauthorized - no Bearer", errAuthHeader)
+	}
+
+	claims, err := jwt.HMACCheck([]byte(headerParts[1]), []byte(app.config.jwt.secret))
+	if err != nil {
+		return accessToken{}, errors.New("unathorized - failed hmac check")
+	}
+
+	if !claims.Valid(time.Now()) {
+		return accessToken{}, errors.New("unathorized - token is expired")
+	}
+
+	if !claims.AcceptAudience("mydomain.com") {
+		return accessToken{}, errors.New("unathorized - invalid audience")
+	}
+
+	if claims.Issuer != "mydomain.com" {
+		return accessToken{}, errors.New("unathorized - invalid issuer")
+	}
+
+	userID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return accessToken{}, errors.New("could not get user ID from token")
+	}
+
+	role, ok := claims.String("role")
+	if !ok {
+		role = models.RoleViewer
+	}
+
+	token := accessToken{ID: claims.ID, UserID: int(userID), Role: role}
+	if claims.Expires != nil {
+		token.Expires = claims.Expires.Time()
+	}
+
+	return token, nil
+}
+
+// tokenDenied reports whether an access token was revoked by logging out
+func (app *application) tokenDenied(r *http.Request, token accessToken) (bool, error) {
+	if token.ID == "" {
+		return false, nil
+	}
+	return app.models.DB.TokenDenied(r.Context(), token.ID)
+}
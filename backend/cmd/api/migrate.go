@@ -0,0 +1,66 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/BradPreston/go-movies/backend/models"
+)
+
+// migrate runs the migrate subcommand: migrate up | migrate down [n] | migrate status
+func (app *application) migrate(db *sql.DB, args []string) error {
+	migrator := models.Migrator{DB: db}
+
+	if len(args) == 0 {
+		return errors.New("usage: migrate up | down [n] | status")
+	}
+
+	switch args[0] {
+	case "up":
+		done, err := migrator.Up()
+		for _, m := range done {
+			app.logger.Printf("applied %06d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			app.logger.Println("database is up to date")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+			steps = n
+		}
+
+		done, err := migrator.Down(steps)
+		for _, m := range done {
+			app.logger.Printf("rolled back %06d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+	case "status":
+		status, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, s := range status {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			app.logger.Printf("%06d_%s: %s", s.Version, s.Name, applied)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+
+	return nil
+}
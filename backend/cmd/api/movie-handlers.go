@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
@@ -24,6 +25,13 @@ type jsonResponse struct {
 func (app *application) getOneMovie(w http.ResponseWriter, r *http.Request) {
 	params := httprouter.ParamsFromContext(r.Context())
 
+	// httprouter cannot register /v1/movies/search next to /v1/movies/:id,
+	// so the search path arrives here
+	if params.ByName("id") == "search" {
+		app.searchMovies(w, r)
+		return
+	}
+
 	id, err := strconv.Atoi(params.ByName("id"))
 	if err != nil {
 		app.logger.Println(errors.New("invalid id parameter"))
@@ -31,12 +39,19 @@ func (app *application) getOneMovie(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	movie, err := app.models.DB.Get(id)
+	movie, err := app.models.DB.Get(r.Context(), id)
 	if err != nil {
-		app.errorJSON(w, err)
+		app.storeErrorJSON(w, err)
 		return
 	}
 
+	if err = app.markWatchlist(r, movie); err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", movieETag(movie))
+
 	if err = app.writeJSON(w, http.StatusOK, movie, "movie"); err != nil {
 		app.errorJSON(w, err)
 		return
@@ -45,13 +60,65 @@ func (app *application) getOneMovie(w http.ResponseWriter, r *http.Request) {
 
 // getAllMovies gets all movies from the database
 func (app *application) getAllMovies(w http.ResponseWriter, r *http.Request) {
-	movies, err := app.models.DB.All()
+	filter, err := app.readMovieFilter(r)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	if err = app.writeJSON(w, http.StatusOK, movies, "movies"); err != nil {
+	movies, next, err := app.models.DB.All(r.Context(), filter)
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	if err = app.markWatchlist(r, movies...); err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, movies, "movies", pageMeta(next)); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
+
+// searchMovies runs a full-text search over movie titles and descriptions.
+// Results are not paged: only the best matches, up to limit, are returned.
+func (app *application) searchMovies(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		app.errorJSON(w, errors.New("missing search query"))
+		return
+	}
+
+	if r.URL.Query().Get("cursor") != "" {
+		app.errorJSON(w, errors.New("search results are not paged, use limit instead of cursor"))
+		return
+	}
+
+	limit, err := app.readLimit(r)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	results, err := app.models.DB.Search(r.Context(), q, limit)
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	movies := make([]*models.Movie, len(results))
+	for i := range results {
+		movies[i] = &results[i].Movie
+	}
+	if err = app.markWatchlist(r, movies...); err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, results, "movies"); err != nil {
 		app.errorJSON(w, err)
 		return
 	}
@@ -59,9 +126,9 @@ func (app *application) getAllMovies(w http.ResponseWriter, r *http.Request) {
 
 // getAllGenres gets all of the genres from the database
 func (app *application) getAllGenres(w http.ResponseWriter, r *http.Request) {
-	genres, err := app.models.DB.GenresAll()
+	genres, err := app.models.DB.GenresAll(r.Context())
 	if err != nil {
-		app.errorJSON(w, err)
+		app.storeErrorJSON(w, err)
 		return
 	}
 
@@ -71,6 +138,7 @@ func (app *application) getAllGenres(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// getAllMoviesByGenre gets all movies of one genre from the database
 func (app *application) getAllMoviesByGenre(w http.ResponseWriter, r *http.Request) {
 	params := httprouter.ParamsFromContext(r.Context())
 
@@ -80,19 +148,31 @@ func (app *application) getAllMoviesByGenre(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	movies, err := app.models.DB.All(genreID)
+	filter, err := app.readMovieFilter(r)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
+	filter.Genres = []int{genreID}
 
-	if err = app.writeJSON(w, http.StatusOK, movies, "movies"); err != nil {
+	movies, next, err := app.models.DB.All(r.Context(), filter)
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	if err = app.markWatchlist(r, movies...); err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, movies, "movies", pageMeta(next)); err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 }
 
-// deleteMovie deletes a movie from the database
+// deleteMovie moves a movie to the trash
 func (app *application) deleteMovie(w http.ResponseWriter, r *http.Request) {
 	params := httprouter.ParamsFromContext(r.Context())
 
@@ -102,8 +182,8 @@ func (app *application) deleteMovie(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err = app.models.DB.DeleteMovie(id); err != nil {
-		app.errorJSON(w, err)
+	if err = app.models.DB.DeleteMovie(r.Context(), id, app.actor(r)); err != nil {
+		app.storeErrorJSON(w, err)
 		return
 	}
 
@@ -126,9 +206,13 @@ type MoviePayload struct {
 	Runtime     string `json:"runtime"`
 	Rating      string `json:"rating"`
 	MPAARating  string `json:"mpaa_rating"`
+	GenreIDs    []int  `json:"genre_ids"`
+	Version     string `json:"version"`
 }
 
-// updateMovie updates a movie in the database
+// editMovie inserts or updates a movie and responds with the saved movie.
+// Updates must say which version of the movie they are based on, either with
+// an If-Match header holding the movie's ETag or with the version field.
 func (app *application) editMovie(w http.ResponseWriter, r *http.Request) {
 	var payload MoviePayload
 
@@ -139,6 +223,7 @@ func (app *application) editMovie(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var movie models.Movie
+	ifMatch := r.Header.Get("If-Match")
 
 	if payload.ID != "0" {
 		id, err := strconv.Atoi(payload.ID)
@@ -147,9 +232,25 @@ func (app *application) editMovie(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		m, err := app.models.DB.Get(id)
+		m, err := app.models.DB.Get(r.Context(), id)
 		if err != nil {
-			app.errorJSON(w, err)
+			app.storeErrorJSON(w, err)
+			return
+		}
+
+		switch {
+		case ifMatch != "":
+			if !etagMatches(ifMatch, movieETag(m)) {
+				app.errorJSON(w, models.ErrEditConflict, http.StatusPreconditionFailed)
+				return
+			}
+		case payload.Version != "":
+			if m.Version, err = strconv.Atoi(payload.Version); err != nil {
+				app.errorJSON(w, errors.New("invalid version"))
+				return
+			}
+		default:
+			app.errorJSON(w, errors.New("an If-Match header or version field is required"), http.StatusPreconditionRequired)
 			return
 		}
 
@@ -181,31 +282,54 @@ func (app *application) editMovie(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	movie.MPAARating = payload.MPAARating
+
+	// leave the genres of an existing movie alone unless genre_ids is sent
+	if payload.GenreIDs != nil {
+		movie.MovieGenre = make(map[int]string, len(payload.GenreIDs))
+		for _, id := range payload.GenreIDs {
+			movie.MovieGenre[id] = ""
+		}
+	}
 	movie.CreatedAt = time.Now()
 	movie.UpdatedAt = time.Now()
 
+	if err = movie.Validate(); err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
 	if movie.Poster == "" {
 		movie = getPoster(movie)
 	}
 
+	status := http.StatusOK
 	if movie.ID == 0 {
-		if err = app.models.DB.InsertMovie(movie); err != nil {
-			app.errorJSON(w, err)
+		if movie.ID, err = app.models.DB.InsertMovie(r.Context(), movie, app.actor(r)); err != nil {
+			app.storeErrorJSON(w, err)
 			return
 		}
+		status = http.StatusCreated
+		w.Header().Set("Location", fmt.Sprintf("/v1/movies/%d", movie.ID))
 	} else {
-		if err = app.models.DB.UpdateMovie(movie); err != nil {
-			app.errorJSON(w, err)
+		if err = app.models.DB.UpdateMovie(r.Context(), movie, app.actor(r)); err != nil {
+			if errors.Is(err, models.ErrEditConflict) && ifMatch != "" {
+				app.errorJSON(w, err, http.StatusPreconditionFailed)
+				return
+			}
+			app.storeErrorJSON(w, err)
 			return
 		}
 	}
 
-	ok := jsonResponse{
-		OK: true,
+	saved, err := app.models.DB.Get(r.Context(), movie.ID)
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, ok, "response")
-	if err != nil {
+	w.Header().Set("ETag", movieETag(saved))
+
+	if err = app.writeJSON(w, status, saved, "movie"); err != nil {
 		app.errorJSON(w, err)
 		return
 	}
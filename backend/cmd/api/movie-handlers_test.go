@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/BradPreston/go-movies/backend/models"
+)
+
+func TestGetOneMovie(t *testing.T) {
+	app := newTestApplication(t)
+	id := app.testMovie(t, "The Matrix")
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"existing movie", fmt.Sprintf("/v1/movies/%d", id), http.StatusOK},
+		{"missing movie", "/v1/movies/999", http.StatusNotFound},
+		{"invalid id", "/v1/movies/abc", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serve(t, app, http.MethodGet, tt.path, "", "")
+			if rr.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rr.Code, tt.status, rr.Body)
+			}
+		})
+	}
+
+	rr := serve(t, app, http.MethodGet, fmt.Sprintf("/v1/movies/%d", id), "", "")
+
+	var body struct {
+		Movie models.Movie `json:"movie"`
+	}
+	decode(t, rr, &body)
+	if body.Movie.ID != id || body.Movie.Title != "The Matrix" {
+		t.Errorf("got movie %d %q, want %d The Matrix", body.Movie.ID, body.Movie.Title, id)
+	}
+	if etag := rr.Header().Get("ETag"); etag != fmt.Sprintf(`"%d-1"`, id) {
+		t.Errorf("ETag = %s, want \"%d-1\"", etag, id)
+	}
+}
+
+func TestGetAllMovies(t *testing.T) {
+	app := newTestApplication(t)
+	for _, title := range []string{"Alien", "Brazil", "Casablanca"} {
+		app.testMovie(t, title)
+	}
+
+	var page struct {
+		Movies     []models.Movie `json:"movies"`
+		NextCursor *string        `json:"next_cursor"`
+	}
+
+	rr := serve(t, app, http.MethodGet, "/v1/movies?limit=2", "", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rr.Code, rr.Body)
+	}
+	decode(t, rr, &page)
+	if len(page.Movies) != 2 || page.Movies[0].Title != "Alien" || page.Movies[1].Title != "Brazil" {
+		t.Fatalf("first page = %v, want Alien and Brazil", page.Movies)
+	}
+	if page.NextCursor == nil {
+		t.Fatal("first page has no next_cursor")
+	}
+
+	rr = serve(t, app, http.MethodGet, "/v1/movies?limit=2&cursor="+*page.NextCursor, "", "")
+	page.Movies, page.NextCursor = nil, nil
+	decode(t, rr, &page)
+	if len(page.Movies) != 1 || page.Movies[0].Title != "Casablanca" {
+		t.Fatalf("second page = %v, want Casablanca", page.Movies)
+	}
+	if page.NextCursor != nil {
+		t.Errorf("last page has next_cursor %q", *page.NextCursor)
+	}
+
+	rr = serve(t, app, http.MethodGet, "/v1/movies?limit=1000", "", "")
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("limit above the maximum: status = %d, want 400", rr.Code)
+	}
+}
+
+func TestEditMovie(t *testing.T) {
+	app := newTestApplication(t)
+	editor := app.testToken(t, 1, models.RoleEditor)
+
+	payload := func(id, version string) string {
+		return fmt.Sprintf(`{"id":%q,"title":"Heat","description":"Crime","release_date":"1995-12-15",`+
+			`"runtime":"170","rating":"5","mpaa_rating":"R","version":%q}`, id, version)
+	}
+
+	rr := serve(t, app, http.MethodPost, "/v1/admin/editmovie", payload("0", ""), editor)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("insert: status = %d, want 201: %s", rr.Code, rr.Body)
+	}
+	var body struct {
+		Movie models.Movie `json:"movie"`
+	}
+	decode(t, rr, &body)
+	id := fmt.Sprint(body.Movie.ID)
+	if body.Movie.Year != 1995 || body.Movie.Version != 1 {
+		t.Errorf("inserted movie has year %d, version %d, want 1995, 1", body.Movie.Year, body.Movie.Version)
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		header []string
+		status int
+	}{
+		{"no version", payload(id, ""), nil, http.StatusPreconditionRequired},
+		{"stale If-Match", payload(id, ""), []string{"If-Match", `"` + id + `-7"`}, http.StatusPreconditionFailed},
+		{"stale version", payload(id, "7"), nil, http.StatusConflict},
+		{"current version", payload(id, "1"), nil, http.StatusOK},
+		{"version used up", payload(id, "1"), nil, http.StatusConflict},
+		{"current If-Match", payload(id, ""), []string{"If-Match", `"` + id + `-2"`}, http.StatusOK},
+		{"missing movie", payload("999", "1"), nil, http.StatusNotFound},
+		{"invalid date", `{"id":"0","title":"Heat","release_date":"soon","runtime":"170","rating":"5"}`, nil, http.StatusBadRequest},
+		{"rating above 5", `{"id":"0","title":"Heat","release_date":"1995-12-15","runtime":"170","rating":"9"}`, nil, http.StatusUnprocessableEntity},
+		{"negative runtime", `{"id":"0","title":"Heat","release_date":"1995-12-15","runtime":"-1","rating":"5"}`, nil, http.StatusUnprocessableEntity},
+		{"no title", `{"id":"0","title":"","release_date":"1995-12-15","runtime":"170","rating":"5"}`, nil, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serve(t, app, http.MethodPost, "/v1/admin/editmovie", tt.body, editor, tt.header...)
+			if rr.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rr.Code, tt.status, rr.Body)
+			}
+		})
+	}
+
+	if rr := serve(t, app, http.MethodPost, "/v1/admin/editmovie", payload("0", ""), ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("without a token: status = %d, want 400", rr.Code)
+	}
+}
+
+func TestDeleteMovie(t *testing.T) {
+	app := newTestApplication(t)
+	editor := app.testToken(t, 1, models.RoleEditor)
+	id := app.testMovie(t, "Fargo")
+	path := fmt.Sprintf("/v1/admin/deletemovie/%d", id)
+
+	if rr := serve(t, app, http.MethodDelete, path, "", editor); rr.Code != http.StatusOK {
+		t.Fatalf("delete: status = %d, want 200: %s", rr.Code, rr.Body)
+	}
+
+	if rr := serve(t, app, http.MethodGet, fmt.Sprintf("/v1/movies/%d", id), "", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("get after delete: status = %d, want 404", rr.Code)
+	}
+
+	if rr := serve(t, app, http.MethodDelete, path, "", editor); rr.Code != http.StatusNotFound {
+		t.Errorf("second delete: status = %d, want 404", rr.Code)
+	}
+
+	if rr := serve(t, app, http.MethodDelete, "/v1/admin/deletemovie/abc", "", editor); rr.Code != http.StatusBadRequest {
+		t.Errorf("invalid id: status = %d, want 400", rr.Code)
+	}
+}
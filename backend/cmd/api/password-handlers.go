@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/BradPreston/go-movies/backend/models"
+)
+
+// resetRequested is the response to every reset request, so that it does
+// not tell whether the email is registered
+const resetRequested = "if that email is registered, a password reset link has been sent to it"
+
+type ResetRequestPayload struct {
+	Email string `json:"email"`
+}
+
+type ResetPayload struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// requestPasswordReset emails a single-use reset link to a registered
+// address. The response is the same, and takes as long, whether or not the
+// address is known. Requests are throttled per client IP and per address so
+// that nobody can flood an inbox with reset emails.
+func (app *application) requestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var payload ResetRequestPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	wait, err := app.reserveAttempt(r,
+		throttle{models.IPResetKey(clientIP(r)), resetIPPolicy},
+		throttle{models.AccountResetKey(payload.Email), resetAccountPolicy},
+	)
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+	if wait > 0 {
+		app.tooManyAttempts(w, wait, errTooManyResets)
+		return
+	}
+
+	// only registered addresses cost a store write and an email, so that
+	// work is done after the response
+	app.background(func() {
+		app.mailPasswordReset(payload.Email)
+	})
+
+	ok := jsonResponse{
+		OK:      true,
+		Message: resetRequested,
+	}
+
+	if err = app.writeJSON(w, http.StatusAccepted, ok, "response"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
+
+// mailPasswordReset stores a reset token for the user with the given email,
+// if there is one, and emails it to them. Failures are logged.
+func (app *application) mailPasswordReset(email string) {
+	ctx, cancel := context.WithTimeout(context.Background(), backgroundTimeout)
+	defer cancel()
+
+	user, err := app.models.DB.UserByEmail(ctx, email)
+	if errors.Is(err, models.ErrNotFound) {
+		return
+	}
+	if err != nil {
+		app.logger.Println("password reset:", models.Cause(err))
+		return
+	}
+
+	token, hash, err := models.NewSecret()
+	if err != nil {
+		app.logger.Println("password reset:", err)
+		return
+	}
+
+	reset := models.PasswordReset{
+		UserID:    user.ID,
+		Hash:      hash,
+		ExpiresAt: time.Now().Add(models.PasswordResetTTL),
+	}
+	if err = app.models.DB.InsertPasswordReset(ctx, reset); err != nil {
+		app.logger.Println("password reset:", models.Cause(err))
+		return
+	}
+
+	body := fmt.Sprintf("Someone asked to reset the password of your Go Movies account.\n\n"+
+		"Follow this link within %d minutes to choose a new password:\n\n%s%s\n\n"+
+		"If it was not you, ignore this email and your password stays the same.\n",
+		int(models.PasswordResetTTL/time.Minute), app.config.mail.resetURL, url.QueryEscape(token))
+	app.sendMail(user.Email, "Reset your password", body)
+}
+
+// resetPassword sets a new password with a reset token. The token works
+// once, and every session of the user is signed out.
+func (app *application) resetPassword(w http.ResponseWriter, r *http.Request) {
+	var payload ResetPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	hash := models.HashSecret(payload.Token)
+
+	// the token is only used up once the new password has been accepted
+	user, err := app.models.DB.PasswordResetUser(r.Context(), hash)
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	if err = user.SetPassword(payload.Password); err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	if err = app.models.DB.ResetPassword(r.Context(), hash, user.Password); err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	// the owner of the account has proven who they are, so lift any lockout
+	// and let them ask for another reset
+	err = app.models.DB.ClearLoginAttempts(r.Context(), models.AccountLoginKey(user.Email), models.AccountResetKey(user.Email))
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	ok := jsonResponse{
+		OK:      true,
+		Message: "your password has been changed, please log in again",
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, ok, "response"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/BradPreston/go-movies/backend/models"
+)
+
+func TestRequestPasswordReset(t *testing.T) {
+	app := newTestApplication(t)
+	app.testLogin(t, "ada@example.com", models.RoleViewer)
+
+	known := serve(t, app, http.MethodPost, "/v1/password-reset", `{"email":"ada@example.com"}`, "")
+	unknown := serve(t, app, http.MethodPost, "/v1/password-reset", `{"email":"bob@example.com"}`, "")
+
+	if known.Code != http.StatusAccepted || unknown.Code != http.StatusAccepted {
+		t.Fatalf("status = %d and %d, want 202 for both", known.Code, unknown.Code)
+	}
+	if known.Body.String() != unknown.Body.String() {
+		t.Errorf("responses differ: %s and %s", known.Body, unknown.Body)
+	}
+
+	app.wg.Wait()
+	sent := app.mailer.(*testMailer).sent
+	if len(sent) != 1 || sent[0].to != "ada@example.com" {
+		t.Fatalf("sent %v, want one email to ada@example.com", sent)
+	}
+	if !strings.Contains(sent[0].body, app.config.mail.resetURL) {
+		t.Errorf("email has no reset link: %s", sent[0].body)
+	}
+}
+
+func TestRequestPasswordResetIsThrottled(t *testing.T) {
+	app := newTestApplication(t)
+
+	// the first requests for an address are free, registered or not
+	for i := 0; i < resetAccountPolicy.free; i++ {
+		if rr := serve(t, app, http.MethodPost, "/v1/password-reset", `{"email":"bob@example.com"}`, ""); rr.Code != http.StatusAccepted {
+			t.Fatalf("request %d: status = %d, want 202", i+1, rr.Code)
+		}
+	}
+
+	rr := serve(t, app, http.MethodPost, "/v1/password-reset", `{"email":"Bob@Example.com"}`, "")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("request past the limit: status = %d, want 429", rr.Code)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Error("429 response has no Retry-After header")
+	}
+
+	if rr := serve(t, app, http.MethodPost, "/v1/password-reset", `{"email":"carol@example.com"}`, ""); rr.Code != http.StatusAccepted {
+		t.Errorf("another address: status = %d, want 202", rr.Code)
+	}
+
+	app.wg.Wait()
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BradPreston/go-movies/backend/models"
+	"github.com/julienschmidt/httprouter"
+)
+
+type PersonPayload struct {
+	Name      string `json:"name"`
+	BirthDate string `json:"birth_date"`
+	Biography string `json:"biography"`
+}
+
+type CreditPayload struct {
+	PersonID     int    `json:"person_id"`
+	Role         string `json:"role"`
+	Character    string `json:"character"`
+	BillingOrder int    `json:"billing_order"`
+}
+
+// readPersonPayload decodes and validates a person from the request body
+func (app *application) readPersonPayload(r *http.Request) (models.Person, error) {
+	var payload PersonPayload
+	var person models.Person
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return person, err
+	}
+
+	person.Name = strings.TrimSpace(payload.Name)
+	if person.Name == "" {
+		return person, errors.New("name is required")
+	}
+	if len(person.Name) > 255 {
+		return person, errors.New("name must be at most 255 characters")
+	}
+
+	if payload.BirthDate != "" {
+		birthDate, err := time.Parse("2006-01-02", payload.BirthDate)
+		if err != nil {
+			return person, errors.New("birth_date must be a YYYY-MM-DD date")
+		}
+		person.BirthDate = &birthDate
+	}
+	person.Biography = payload.Biography
+
+	return person, nil
+}
+
+// readCreditPayload decodes a credit from the request body
+func (app *application) readCreditPayload(r *http.Request) (models.Credit, error) {
+	var payload CreditPayload
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return models.Credit{}, err
+	}
+
+	return models.Credit{
+		PersonID:     payload.PersonID,
+		Role:         strings.ToLower(strings.TrimSpace(payload.Role)),
+		Character:    strings.TrimSpace(payload.Character),
+		BillingOrder: payload.BillingOrder,
+	}, nil
+}
+
+// getPeople lists people, optionally only those whose name contains the
+// name parameter
+func (app *application) getPeople(w http.ResponseWriter, r *http.Request) {
+	page, err := app.readPage(r)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	people, next, err := app.models.DB.People(r.Context(), r.URL.Query().Get("name"), page)
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, people, "people", pageMeta(next)); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
+
+// getPerson gets one person with their filmography
+func (app *application) getPerson(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	person, err := app.models.DB.Person(r.Context(), id)
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, person, "person"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
+
+// createPerson adds a person
+func (app *application) createPerson(w http.ResponseWriter, r *http.Request) {
+	person, err := app.readPersonPayload(r)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	saved, err := app.models.DB.InsertPerson(r.Context(), person, app.actor(r))
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/v1/people/"+strconv.Itoa(saved.ID))
+
+	if err = app.writeJSON(w, http.StatusCreated, saved, "person"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
+
+// updatePerson changes the details of a person
+func (app *application) updatePerson(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	person, err := app.readPersonPayload(r)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	person.ID = id
+
+	if err = app.models.DB.UpdatePerson(r.Context(), person, app.actor(r)); err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, person, "person"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
+
+// deletePerson deletes a person who is not credited on any movie
+func (app *application) deletePerson(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if err = app.models.DB.DeletePerson(r.Context(), id, app.actor(r)); err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	ok := jsonResponse{
+		OK: true,
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, ok, "response"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
+
+// createCredit credits a person on a movie
+func (app *application) createCredit(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	movieID, err := strconv.Atoi(params.ByName("id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	credit, err := app.readCreditPayload(r)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	credit.MovieID = movieID
+
+	saved, err := app.models.DB.InsertCredit(r.Context(), credit, app.actor(r))
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	if err = app.writeJSON(w, http.StatusCreated, saved, "credit"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
+
+// updateCredit changes the role, character and billing order of a credit
+func (app *application) updateCredit(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	credit, err := app.readCreditPayload(r)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	credit.ID = id
+
+	if err = app.models.DB.UpdateCredit(r.Context(), credit, app.actor(r)); err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	ok := jsonResponse{
+		OK: true,
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, ok, "response"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
+
+// deleteCredit removes a credit
+func (app *application) deleteCredit(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if err = app.models.DB.DeleteCredit(r.Context(), id, app.actor(r)); err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	ok := jsonResponse{
+		OK: true,
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, ok, "response"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
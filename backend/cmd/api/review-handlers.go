@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/BradPreston/go-movies/backend/models"
+	"github.com/julienschmidt/httprouter"
+)
+
+type ReviewPayload struct {
+	Score int    `json:"score"`
+	Body  string `json:"body"`
+}
+
+// readReview reads the movie ID parameter and the review in the request
+// body, as written by the authenticated user
+func (app *application) readReview(r *http.Request) (models.Review, error) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	var review models.Review
+	var err error
+
+	if review.MovieID, err = strconv.Atoi(params.ByName("id")); err != nil {
+		return review, err
+	}
+
+	var payload ReviewPayload
+	if err = json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return review, err
+	}
+
+	review.UserID = contextGetUserID(r)
+	review.Score = payload.Score
+	review.Body = strings.TrimSpace(payload.Body)
+
+	return review, nil
+}
+
+// getReviews lists the reviews of a movie, newest first
+func (app *application) getReviews(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	movieID, err := strconv.Atoi(params.ByName("id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	page, err := app.readPage(r)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	reviews, next, err := app.models.DB.Reviews(r.Context(), movieID, page)
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, reviews, "reviews", pageMeta(next)); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
+
+// createReview adds the authenticated user's review of a movie
+func (app *application) createReview(w http.ResponseWriter, r *http.Request) {
+	review, err := app.readReview(r)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	saved, err := app.models.DB.InsertReview(r.Context(), review)
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	if err = app.writeJSON(w, http.StatusCreated, saved, "review"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
+
+// updateReview changes the authenticated user's review of a movie
+func (app *application) updateReview(w http.ResponseWriter, r *http.Request) {
+	review, err := app.readReview(r)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	saved, err := app.models.DB.UpdateReview(r.Context(), review)
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, saved, "review"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
+
+// deleteReview removes the authenticated user's review of a movie
+func (app *application) deleteReview(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	movieID, err := strconv.Atoi(params.ByName("id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if err = app.models.DB.DeleteReview(r.Context(), movieID, contextGetUserID(r)); err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	ok := jsonResponse{
+		OK: true,
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, ok, "response"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/BradPreston/go-movies/backend/models"
+	"github.com/julienschmidt/httprouter"
+)
+
+// getRevisions lists the revisions of a movie, newest first
+func (app *application) getRevisions(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	revisions, err := app.models.DB.Revisions(r.Context(), id)
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, revisions, "revisions"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
+
+// diffRevisions compares two revisions of a movie, or a revision with the
+// current movie when "to" is omitted or "current"
+func (app *application) diffRevisions(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	from, err := app.revisionMovie(r.Context(), id, r.URL.Query().Get("from"))
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	to, err := app.revisionMovie(r.Context(), id, r.URL.Query().Get("to"))
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	changes, err := models.DiffMovies(from, to)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, changes, "changes"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
+
+// revisionMovie returns a movie as it was in a revision, or as it is now
+// when revision is empty or "current"
+func (app *application) revisionMovie(ctx context.Context, movieID int, revision string) (*models.Movie, error) {
+	if revision == "" || revision == "current" {
+		return app.models.DB.Get(ctx, movieID)
+	}
+
+	n, err := strconv.Atoi(revision)
+	if err != nil {
+		return nil, models.ErrRevisionNotFound
+	}
+
+	rev, err := app.models.DB.Revision(ctx, movieID, n)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rev.Movie, nil
+}
+
+type RevertPayload struct {
+	Version string `json:"version"`
+}
+
+// revertMovie restores a movie to one of its revisions. Like an edit, it
+// must say which version of the movie it replaces, either with an If-Match
+// header holding the movie's ETag or with the version field.
+func (app *application) revertMovie(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	revision, err := strconv.Atoi(params.ByName("revision"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	var payload RevertPayload
+
+	// the body is optional when the version is in the If-Match header
+	if err = json.NewDecoder(r.Body).Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		app.errorJSON(w, err)
+		return
+	}
+
+	var version int
+	ifMatch := r.Header.Get("If-Match")
+
+	switch {
+	case ifMatch != "":
+		var ok bool
+		if version, ok = ifMatchVersion(ifMatch, id); !ok {
+			app.errorJSON(w, models.ErrEditConflict, http.StatusPreconditionFailed)
+			return
+		}
+	case payload.Version != "":
+		if version, err = strconv.Atoi(payload.Version); err != nil {
+			app.errorJSON(w, errors.New("invalid version"))
+			return
+		}
+	default:
+		app.errorJSON(w, errors.New("an If-Match header or version field is required"), http.StatusPreconditionRequired)
+		return
+	}
+
+	if err = app.models.DB.RevertMovie(r.Context(), id, revision, version, app.actor(r)); err != nil {
+		if errors.Is(err, models.ErrEditConflict) && ifMatch != "" {
+			app.errorJSON(w, err, http.StatusPreconditionFailed)
+			return
+		}
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	movie, err := app.models.DB.Get(r.Context(), id)
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", movieETag(movie))
+
+	if err = app.writeJSON(w, http.StatusOK, movie, "movie"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
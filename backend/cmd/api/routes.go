@@ -4,15 +4,14 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/BradPreston/go-movies/backend/models"
 	"github.com/julienschmidt/httprouter"
 	"github.com/justinas/alice"
 )
 
 func (app *application) wrap(next http.Handler) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-		type myParams string
-		var p myParams = "params"
-		ctx := context.WithValue(r.Context(), p, ps)
+		ctx := context.WithValue(r.Context(), httprouter.ParamsKey, ps)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	}
 }
@@ -20,16 +19,56 @@ func (app *application) wrap(next http.Handler) httprouter.Handle {
 func (app *application) routes() http.Handler {
 	router := httprouter.New()
 	secure := alice.New(app.checkToken)
+	optional := alice.New(app.optionalToken)
+	editor := secure.Append(app.requireRole(models.RoleEditor))
+	admin := secure.Append(app.requireRole(models.RoleAdmin))
 	router.HandlerFunc(http.MethodGet, "/status", app.statusHandler)
 
 	router.HandlerFunc(http.MethodPost, "/v1/graphql", app.moviesGraphQL)
 
 	router.HandlerFunc(http.MethodPost, "/v1/login", app.Login)
-	router.HandlerFunc(http.MethodGet, "/v1/movies/:id", app.getOneMovie)
-	router.HandlerFunc(http.MethodGet, "/v1/movies", app.getAllMovies)
+	router.HandlerFunc(http.MethodPost, "/v1/register", app.Register)
+	router.HandlerFunc(http.MethodPost, "/v1/refresh", app.Refresh)
+	router.HandlerFunc(http.MethodPost, "/v1/password-reset", app.requestPasswordReset)
+	router.HandlerFunc(http.MethodPost, "/v1/password-reset/confirm", app.resetPassword)
+	router.POST("/v1/logout", app.wrap(secure.ThenFunc(app.Logout)))
+	router.GET("/v1/movies/:id", app.wrap(optional.ThenFunc(app.getOneMovie)))
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id/reviews", app.getReviews)
+	router.GET("/v1/movies", app.wrap(optional.ThenFunc(app.getAllMovies)))
 	router.HandlerFunc(http.MethodGet, "/v1/genres", app.getAllGenres)
-	router.HandlerFunc(http.MethodGet, "/v1/genres/:id", app.getAllMoviesByGenre)
-	router.POST("/v1/admin/editmovie", app.wrap(secure.ThenFunc(app.editMovie)))
-	router.DELETE("/v1/admin/deletemovie/:id", app.wrap(secure.ThenFunc(app.deleteMovie)))
-	return app.enableCORS(router)
+	router.GET("/v1/genres/:id", app.wrap(optional.ThenFunc(app.getAllMoviesByGenre)))
+	router.HandlerFunc(http.MethodGet, "/v1/people", app.getPeople)
+	router.HandlerFunc(http.MethodGet, "/v1/people/:id", app.getPerson)
+	router.POST("/v1/movies/:id/reviews", app.wrap(secure.ThenFunc(app.createReview)))
+	router.PUT("/v1/movies/:id/reviews", app.wrap(secure.ThenFunc(app.updateReview)))
+	router.DELETE("/v1/movies/:id/reviews", app.wrap(secure.ThenFunc(app.deleteReview)))
+	router.GET("/v1/me/watchlist", app.wrap(secure.ThenFunc(app.getList(models.ListWatchlist))))
+	router.PUT("/v1/me/watchlist/:id", app.wrap(secure.ThenFunc(app.addToList(models.ListWatchlist))))
+	router.DELETE("/v1/me/watchlist/:id", app.wrap(secure.ThenFunc(app.removeFromList(models.ListWatchlist))))
+	router.GET("/v1/me/favourites", app.wrap(secure.ThenFunc(app.getList(models.ListFavourites))))
+	router.PUT("/v1/me/favourites/:id", app.wrap(secure.ThenFunc(app.addToList(models.ListFavourites))))
+	router.DELETE("/v1/me/favourites/:id", app.wrap(secure.ThenFunc(app.removeFromList(models.ListFavourites))))
+	router.POST("/v1/admin/editmovie", app.wrap(editor.ThenFunc(app.editMovie)))
+	router.DELETE("/v1/admin/deletemovie/:id", app.wrap(editor.ThenFunc(app.deleteMovie)))
+	router.GET("/v1/admin/export", app.wrap(editor.ThenFunc(app.exportMovies)))
+	router.POST("/v1/admin/import", app.wrap(editor.ThenFunc(app.importMovies)))
+	router.GET("/v1/admin/trash", app.wrap(editor.ThenFunc(app.getTrash)))
+	router.GET("/v1/admin/audit", app.wrap(admin.ThenFunc(app.getAuditLog)))
+	router.DELETE("/v1/admin/trash", app.wrap(admin.ThenFunc(app.purgeTrash)))
+	router.POST("/v1/admin/trash/:id/restore", app.wrap(editor.ThenFunc(app.restoreMovie)))
+	router.GET("/v1/admin/movies/:id/revisions", app.wrap(editor.ThenFunc(app.getRevisions)))
+	router.GET("/v1/admin/movies/:id/diff", app.wrap(editor.ThenFunc(app.diffRevisions)))
+	router.POST("/v1/admin/movies/:id/revert/:revision", app.wrap(editor.ThenFunc(app.revertMovie)))
+	router.POST("/v1/admin/movies/:id/credits", app.wrap(editor.ThenFunc(app.createCredit)))
+	router.PUT("/v1/admin/credits/:id", app.wrap(editor.ThenFunc(app.updateCredit)))
+	router.DELETE("/v1/admin/credits/:id", app.wrap(editor.ThenFunc(app.deleteCredit)))
+	router.POST("/v1/admin/people", app.wrap(editor.ThenFunc(app.createPerson)))
+	router.PUT("/v1/admin/people/:id", app.wrap(editor.ThenFunc(app.updatePerson)))
+	router.DELETE("/v1/admin/people/:id", app.wrap(editor.ThenFunc(app.deletePerson)))
+	router.PUT("/v1/admin/users/:id/role", app.wrap(admin.ThenFunc(app.setUserRole)))
+	router.POST("/v1/admin/users/:id/unlock", app.wrap(admin.ThenFunc(app.unlockUser)))
+	router.POST("/v1/admin/genres", app.wrap(editor.ThenFunc(app.createGenre)))
+	router.PUT("/v1/admin/genres/:id", app.wrap(editor.ThenFunc(app.renameGenre)))
+	router.DELETE("/v1/admin/genres/:id", app.wrap(editor.ThenFunc(app.deleteGenre)))
+	return app.enableCORS(app.requestID(router))
 }
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BradPreston/go-movies/backend/models"
+)
+
+// testMailer records the emails it is asked to send
+type testMailer struct {
+	mu   sync.Mutex
+	sent []testMail
+}
+
+type testMail struct {
+	to, subject, body string
+}
+
+func (m *testMailer) Send(ctx context.Context, to, subject, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, testMail{to: to, subject: subject, body: body})
+	return nil
+}
+
+// newTestApplication returns an application backed by an empty memory store
+func newTestApplication(t *testing.T) *application {
+	t.Helper()
+
+	var cfg config
+	cfg.env = "testing"
+	cfg.jwt.secret = "test-secret"
+	cfg.jwt.accessTTL = time.Minute
+	cfg.jwt.refreshTTL = time.Hour
+	cfg.trash.retention = time.Hour
+	cfg.mail.resetURL = "http://localhost/reset?token="
+
+	return &application{
+		config: cfg,
+		logger: log.New(io.Discard, "", 0),
+		models: models.NewMemoryModels(),
+		mailer: &testMailer{},
+	}
+}
+
+// testToken returns an access token for a user with the given role
+func (app *application) testToken(t *testing.T, userID int, role string) string {
+	t.Helper()
+
+	token, err := app.issueToken(&models.User{ID: userID, Role: role})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(token)
+}
+
+// testMovie inserts a movie into the store and returns its ID
+func (app *application) testMovie(t *testing.T, title string) int {
+	t.Helper()
+
+	id, err := app.models.DB.InsertMovie(context.Background(), models.Movie{
+		Title:       title,
+		Description: "A test movie",
+		Year:        1999,
+		ReleaseDate: time.Date(1999, 3, 31, 0, 0, 0, 0, time.UTC),
+		Runtime:     136,
+		Rating:      4,
+		MPAARating:  "R",
+	}, models.Actor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// serve runs a request through the routes of app. header holds alternating
+// header names and values; an empty token sends no Authorization header.
+func serve(t *testing.T, app *application, method, path, body, token string, header ...string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+
+	req := httptest.NewRequest(method, path, r)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+
+	rr := httptest.NewRecorder()
+	app.routes().ServeHTTP(rr, req)
+	return rr
+}
+
+// decode unmarshals the JSON body of a response into dst
+func decode(t *testing.T, rr *httptest.ResponseRecorder, dst interface{}) {
+	t.Helper()
+
+	if err := json.Unmarshal(rr.Body.Bytes(), dst); err != nil {
+		t.Fatalf("decoding %q: %v", rr.Body.String(), err)
+	}
+}
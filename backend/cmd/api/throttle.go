@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/BradPreston/go-movies/backend/models"
+)
+
+// Failed logins older than loginAttemptWindow are forgotten. Delays between
+// attempts double from minLoginDelay up to maxLoginDelay.
+const (
+	loginAttemptWindow = 24 * time.Hour
+	minLoginDelay      = time.Second
+	maxLoginDelay      = 15 * time.Minute
+)
+
+// errTooManyLogins and errTooManyResets are reported, with a Retry-After
+// header, to throttled logins and password reset requests
+var (
+	errTooManyLogins = errors.New("too many failed login attempts, try again later")
+	errTooManyResets = errors.New("too many password reset requests, try again later")
+)
+
+// loginPolicy says how many failed logins are allowed before each further
+// attempt has to wait, and after how many failures logins are refused for
+// lockout. A zero lockAfter never locks. Password reset requests, which
+// never succeed, are throttled the same way.
+type loginPolicy struct {
+	free      int
+	lockAfter int
+	lockout   time.Duration
+}
+
+var (
+	// accountPolicy applies to each email address, registered or not, so a
+	// lockout does not tell whether an account exists
+	accountPolicy = loginPolicy{free: 3, lockAfter: 10, lockout: 30 * time.Minute}
+	// ipPolicy applies to each client IP and allows more failures because
+	// an address may be shared by many users
+	ipPolicy = loginPolicy{free: 10}
+	// resetAccountPolicy limits the reset emails sent to one address, so
+	// that it cannot be flooded
+	resetAccountPolicy = loginPolicy{free: 3, lockAfter: 5, lockout: time.Hour}
+	// resetIPPolicy limits the reset requests of one client IP
+	resetIPPolicy = loginPolicy{free: 10, lockAfter: 20, lockout: time.Hour}
+)
+
+// throttle is a key under which attempts are counted and the policy that
+// applies to them
+type throttle struct {
+	key    string
+	policy loginPolicy
+}
+
+// retryAfter returns how long a client has to wait before it may try to log
+// in again after the failures counted in a, or 0 if it may try now
+func (p loginPolicy) retryAfter(a *models.LoginAttempt, now time.Time) time.Duration {
+	if a == nil || now.Sub(a.LastFailure) > loginAttemptWindow || a.Failures < p.free {
+		return 0
+	}
+
+	var wait time.Duration
+	if p.lockAfter > 0 && a.Failures >= p.lockAfter {
+		wait = p.lockout
+	} else {
+		wait = minLoginDelay
+		for i := p.free; i < a.Failures && wait < maxLoginDelay; i++ {
+			wait *= 2
+		}
+		if wait > maxLoginDelay {
+			wait = maxLoginDelay
+		}
+	}
+
+	if remaining := a.LastFailure.Add(wait).Sub(now); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// reserveAttempt counts an attempt under each throttle in turn before it
+// is made, so that concurrent attempts cannot all slip through one check. If
+// a throttle refuses the attempt, the counts already taken are given back
+// and the wait is returned.
+func (app *application) reserveAttempt(r *http.Request, throttles ...throttle) (time.Duration, error) {
+	now := time.Now()
+	since := now.Add(-loginAttemptWindow)
+
+	for i, t := range throttles {
+		policy := t.policy
+		wait, err := app.models.DB.ReserveLogin(r.Context(), t.key, now, since, func(a *models.LoginAttempt) time.Duration {
+			return policy.retryAfter(a, now)
+		})
+		if err != nil || wait > 0 {
+			for _, taken := range throttles[:i] {
+				if rerr := app.models.DB.ReleaseLogin(r.Context(), taken.key); err == nil {
+					err = rerr
+				}
+			}
+			return wait, err
+		}
+	}
+
+	return 0, nil
+}
+
+// reserveLogin counts a login from ip to email as failed before its password
+// is checked. If the login is throttled nothing is counted and the wait is
+// returned.
+func (app *application) reserveLogin(r *http.Request, ip, email string) (time.Duration, error) {
+	return app.reserveAttempt(r,
+		throttle{models.IPLoginKey(ip), ipPolicy},
+		throttle{models.AccountLoginKey(email), accountPolicy},
+	)
+}
+
+// loginSucceeded forgets the failed logins to email and takes back the
+// attempt reserved for the successful login from ip
+func (app *application) loginSucceeded(r *http.Request, ip, email string) error {
+	if err := app.models.DB.ClearLoginAttempts(r.Context(), models.AccountLoginKey(email)); err != nil {
+		return err
+	}
+	return app.models.DB.ReleaseLogin(r.Context(), models.IPLoginKey(ip))
+}
+
+// clientIP returns the IP address of the client. Forwarding headers are not
+// trusted since anyone can set them.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// tooManyAttempts writes err as a 429 response telling the client to retry
+// after wait
+func (app *application) tooManyAttempts(w http.ResponseWriter, wait time.Duration, err error) {
+	// round up so that a client retrying on time is let through
+	w.Header().Set("Retry-After", strconv.Itoa(int((wait+time.Second-1)/time.Second)))
+	app.errorJSON(w, err, http.StatusTooManyRequests)
+}
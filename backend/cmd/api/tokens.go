@@ -1,22 +1,25 @@
 package main
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 
 	"github.com/BradPreston/go-movies/backend/models"
 	"github.com/pascaldekloe/jwt"
-	"golang.org/x/crypto/bcrypt"
 )
 
-var validUser = models.User{
-	ID:       10,
-	Email:    "me@here.com",
-	Password: "$2a$12$1lK8ZlmM90C3EHSpB9smLOAOd2GFame/8mgGOdmNZ/9MliEx2LzW6",
-}
+// dummyPasswordHash is compared against when the email is unknown, so that
+// failed logins take as long whether or not the account exists
+const dummyPasswordHash = "$2a$12$1lK8ZlmM90C3EHSpB9smLOAOd2GFame/8mgGOdmNZ/9MliEx2LzW6"
+
+// errInvalidCredentials is the only error a failed login reports
+var errInvalidCredentials = errors.New("invalid email or password")
 
 type Credentials struct {
 	Username string `json:"email"`
@@ -32,26 +35,195 @@ func (app *application) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	hashedPassword := validUser.Password
+	// the attempt is counted as failed until the password is found to match,
+	// and throttled logins are refused before the password is hashed
+	ip := clientIP(r)
+	wait, err := app.reserveLogin(r, ip, creds.Username)
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+	if wait > 0 {
+		app.tooManyAttempts(w, wait, errTooManyLogins)
+		return
+	}
 
-	if err = bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(creds.Password)); err != nil {
-		app.errorJSON(w, errors.New("password does not match our records"))
+	user, err := app.models.DB.UserByEmail(r.Context(), creds.Username)
+	if errors.Is(err, models.ErrNotFound) {
+		user, err = &models.User{Password: dummyPasswordHash}, nil
+	}
+	if err != nil {
+		app.storeErrorJSON(w, err)
 		return
 	}
 
-	var claims jwt.Claims
-	claims.Subject = fmt.Sprint(validUser.ID)
-	claims.Issued = jwt.NewNumericTime(time.Now())
-	claims.NotBefore = jwt.NewNumericTime(time.Now())
-	claims.Expires = jwt.NewNumericTime(time.Now().Add(24 * time.Hour))
-	claims.Issuer = "mydomain.com"
-	claims.Audiences = []string{"mydomain.com"}
+	match, err := user.PasswordMatches(creds.Password)
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+	if !match || user.ID == 0 {
+		app.errorJSON(w, errInvalidCredentials, http.StatusUnauthorized)
+		return
+	}
+
+	if err = app.loginSucceeded(r, ip, user.Email); err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	refresh, stored, err := models.NewRefreshToken(user.ID, "", app.config.jwt.refreshTTL)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusInternalServerError)
+		return
+	}
+	if err = app.models.DB.InsertRefreshToken(r.Context(), stored); err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	app.writeTokens(w, user, refresh)
+}
+
+type RefreshPayload struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh
+// token. Each refresh token works once; presenting one again signs out every
+// session descending from the same login.
+func (app *application) Refresh(w http.ResponseWriter, r *http.Request) {
+	var payload RefreshPayload
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || payload.RefreshToken == "" {
+		app.errorJSON(w, models.ErrInvalidToken, http.StatusUnauthorized)
+		return
+	}
+
+	// the store fills in the user and family of the token being replaced
+	refresh, next, err := models.NewRefreshToken(0, "", app.config.jwt.refreshTTL)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	user, err := app.models.DB.RotateRefreshToken(r.Context(), models.HashSecret(payload.RefreshToken), next)
+	if errors.Is(err, models.ErrInvalidToken) || errors.Is(err, models.ErrTokenReuse) {
+		app.errorJSON(w, err, http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
 
-	jwtBytes, err := claims.HMACSign(jwt.HS256, []byte(app.config.jwt.secret))
+	app.writeTokens(w, user, refresh)
+}
+
+// Logout revokes the access token of the request and, when the body carries
+// one, the refresh token of the same login
+func (app *application) Logout(w http.ResponseWriter, r *http.Request) {
+	var payload RefreshPayload
+
+	// the body is optional
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if token, ok := contextGetToken(r); ok && token.ID != "" {
+		if err := app.models.DB.DenyToken(r.Context(), token.ID, token.Expires); err != nil {
+			app.storeErrorJSON(w, err)
+			return
+		}
+	}
+
+	if payload.RefreshToken != "" {
+		err := app.models.DB.RevokeRefreshToken(r.Context(), contextGetUserID(r), models.HashSecret(payload.RefreshToken))
+		// an unknown refresh token leaves nothing to sign out
+		if err != nil && !errors.Is(err, models.ErrInvalidToken) {
+			app.storeErrorJSON(w, err)
+			return
+		}
+	}
+
+	ok := jsonResponse{
+		OK: true,
+	}
+
+	if err := app.writeJSON(w, http.StatusOK, ok, "response"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
+
+// writeTokens writes a new access token for a user, as the response, next
+// to a refresh token and the lifetime of the access token in seconds
+func (app *application) writeTokens(w http.ResponseWriter, user *models.User, refresh string) {
+	jwtBytes, err := app.issueToken(user)
 	if err != nil {
 		app.errorJSON(w, errors.New("error signing jwt"))
 		return
 	}
 
-	app.writeJSON(w, http.StatusOK, string(jwtBytes), "response")
+	extra := envelope{
+		"refresh_token": refresh,
+		"expires_in":    int(app.config.jwt.accessTTL / time.Second),
+	}
+
+	app.writeJSON(w, http.StatusOK, string(jwtBytes), "response", extra)
+}
+
+// Register creates an account from an email address and a password that
+// passes the strength checks
+func (app *application) Register(w http.ResponseWriter, r *http.Request) {
+	var creds Credentials
+
+	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	user := models.User{Email: models.NormalizeEmail(creds.Username), Role: models.RoleViewer}
+	if err := user.Validate(); err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+	if err := user.SetPassword(creds.Password); err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	saved, err := app.models.DB.InsertUser(r.Context(), user)
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	if err = app.writeJSON(w, http.StatusCreated, saved, "user"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
+
+// issueToken signs an access token for a user, carrying their role in the
+// role claim and a random ID that logging out adds to the denylist. A role
+// change applies to tokens issued after it.
+func (app *application) issueToken(user *models.User) ([]byte, error) {
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return nil, err
+	}
+
+	var claims jwt.Claims
+	claims.Set = map[string]interface{}{"role": user.Role}
+	claims.ID = hex.EncodeToString(jti)
+	claims.Subject = fmt.Sprint(user.ID)
+	claims.Issued = jwt.NewNumericTime(time.Now())
+	claims.NotBefore = jwt.NewNumericTime(time.Now())
+	claims.Expires = jwt.NewNumericTime(time.Now().Add(app.config.jwt.accessTTL))
+	claims.Issuer = "mydomain.com"
+	claims.Audiences = []string{"mydomain.com"}
+
+	return claims.HMACSign(jwt.HS256, []byte(app.config.jwt.secret))
 }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/BradPreston/go-movies/backend/models"
+)
+
+// testLogin stores a user and a refresh token for them, as a login would,
+// and returns the user and the refresh token
+func (app *application) testLogin(t *testing.T, email, role string) (*models.User, string) {
+	t.Helper()
+
+	user, err := app.models.DB.InsertUser(context.Background(), models.User{Email: email, Password: "hash", Role: role})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refresh, stored, err := models.NewRefreshToken(user.ID, "", app.config.jwt.refreshTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = app.models.DB.InsertRefreshToken(context.Background(), stored); err != nil {
+		t.Fatal(err)
+	}
+
+	return user, refresh
+}
+
+func TestRefreshRotatesAndDetectsReuse(t *testing.T) {
+	app := newTestApplication(t)
+	_, first := app.testLogin(t, "ada@example.com", models.RoleViewer)
+
+	refresh := func(token string) (int, string) {
+		rr := serve(t, app, http.MethodPost, "/v1/refresh", fmt.Sprintf(`{"refresh_token":%q}`, token), "")
+		var body struct {
+			Response     string `json:"response"`
+			RefreshToken string `json:"refresh_token"`
+		}
+		if rr.Code == http.StatusOK {
+			decode(t, rr, &body)
+			if body.Response == "" {
+				t.Error("refresh returned no access token")
+			}
+		}
+		return rr.Code, body.RefreshToken
+	}
+
+	status, second := refresh(first)
+	if status != http.StatusOK || second == "" || second == first {
+		t.Fatalf("first refresh: status %d, refresh token %q", status, second)
+	}
+
+	if status, _ = refresh(first); status != http.StatusUnauthorized {
+		t.Errorf("replayed token: status = %d, want 401", status)
+	}
+
+	if status, _ = refresh(second); status != http.StatusUnauthorized {
+		t.Errorf("newest token after a replay: status = %d, want 401", status)
+	}
+
+	if status, _ = refresh(""); status != http.StatusUnauthorized {
+		t.Errorf("missing token: status = %d, want 401", status)
+	}
+}
+
+func TestLogoutDeniesAccessToken(t *testing.T) {
+	app := newTestApplication(t)
+	user, refresh := app.testLogin(t, "ada@example.com", models.RoleViewer)
+	token := app.testToken(t, user.ID, user.Role)
+
+	if rr := serve(t, app, http.MethodGet, "/v1/me/watchlist", "", token); rr.Code != http.StatusOK {
+		t.Fatalf("before logout: status = %d, want 200: %s", rr.Code, rr.Body)
+	}
+
+	body := fmt.Sprintf(`{"refresh_token":%q}`, refresh)
+	if rr := serve(t, app, http.MethodPost, "/v1/logout", body, token); rr.Code != http.StatusOK {
+		t.Fatalf("logout: status = %d, want 200: %s", rr.Code, rr.Body)
+	}
+
+	if rr := serve(t, app, http.MethodGet, "/v1/me/watchlist", "", token); rr.Code != http.StatusForbidden {
+		t.Errorf("logged out token: status = %d, want 403", rr.Code)
+	}
+
+	if rr := serve(t, app, http.MethodPost, "/v1/refresh", body, ""); rr.Code != http.StatusUnauthorized {
+		t.Errorf("refresh token of the logged out session: status = %d, want 401", rr.Code)
+	}
+
+	// another token of the same user is not affected
+	other := app.testToken(t, user.ID, user.Role)
+	if rr := serve(t, app, http.MethodGet, "/v1/me/watchlist", "", other); rr.Code != http.StatusOK {
+		t.Errorf("other token: status = %d, want 200", rr.Code)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/BradPreston/go-movies/backend/models"
+	"github.com/julienschmidt/httprouter"
+)
+
+// getTrash lists the movies in the trash
+func (app *application) getTrash(w http.ResponseWriter, r *http.Request) {
+	movies, err := app.models.DB.Trash(r.Context())
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, movies, "movies"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
+
+// restoreMovie takes a movie back out of the trash
+func (app *application) restoreMovie(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if err = app.models.DB.RestoreMovie(r.Context(), id, app.actor(r)); err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			app.errorJSON(w, errors.New("movie is not in the trash"), http.StatusNotFound)
+			return
+		}
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	movie, err := app.models.DB.Get(r.Context(), id)
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, movie, "movie"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
+
+// purgeTrash permanently deletes the movies that have been in the trash for
+// longer than the configured retention period
+func (app *application) purgeTrash(w http.ResponseWriter, r *http.Request) {
+	purged, err := app.models.DB.PurgeTrash(r.Context(), time.Now().Add(-app.config.trash.retention), app.actor(r))
+	if err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, purged, "purged"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
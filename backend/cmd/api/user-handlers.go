@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/BradPreston/go-movies/backend/models"
+	"github.com/julienschmidt/httprouter"
+)
+
+type RolePayload struct {
+	Role string `json:"role"`
+}
+
+// setUserRole changes the role of a user. Access tokens already issued keep
+// the old role until they expire; the next refresh or login issues one with
+// the new role.
+func (app *application) setUserRole(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	var payload RolePayload
+	if err = json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if id == contextGetUserID(r) && payload.Role != models.RoleAdmin {
+		app.errorJSON(w, errors.New("you cannot remove your own admin role"), http.StatusConflict)
+		return
+	}
+
+	if err = app.models.DB.UpdateUserRole(r.Context(), id, payload.Role, app.actor(r)); err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	ok := jsonResponse{
+		OK: true,
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, ok, "response"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
+
+// unlockUser lifts the login lockout of a user by forgetting the failed
+// logins to their email address
+func (app *application) unlockUser(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if err = app.models.DB.UnlockUser(r.Context(), id, app.actor(r)); err != nil {
+		app.storeErrorJSON(w, err)
+		return
+	}
+
+	ok := jsonResponse{
+		OK: true,
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, ok, "response"); err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+}
+
+// setRole runs the role subcommand: role email viewer|editor|admin. It is
+// how the first admin is made.
+func (app *application) setRole(args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: role email viewer|editor|admin")
+	}
+
+	ctx := context.Background()
+
+	user, err := app.models.DB.UserByEmail(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	if err = app.models.DB.UpdateUserRole(ctx, user.ID, args[1], models.Actor{}); err != nil {
+		return err
+	}
+
+	app.logger.Printf("%s is now %s", user.Email, args[1])
+	return nil
+}
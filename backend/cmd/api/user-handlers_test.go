@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/BradPreston/go-movies/backend/models"
+)
+
+func TestRequireRole(t *testing.T) {
+	app := newTestApplication(t)
+
+	tests := []struct {
+		chain string
+		path  string
+		role  string
+		want  int
+	}{
+		{"editor", "/v1/admin/trash", models.RoleViewer, http.StatusForbidden},
+		{"editor", "/v1/admin/trash", models.RoleEditor, http.StatusOK},
+		{"editor", "/v1/admin/trash", models.RoleAdmin, http.StatusOK},
+		{"editor", "/v1/admin/trash", "superuser", http.StatusForbidden},
+		{"editor", "/v1/admin/trash", "", http.StatusForbidden},
+		{"admin", "/v1/admin/audit", models.RoleViewer, http.StatusForbidden},
+		{"admin", "/v1/admin/audit", models.RoleEditor, http.StatusForbidden},
+		{"admin", "/v1/admin/audit", models.RoleAdmin, http.StatusOK},
+		{"admin", "/v1/admin/audit", "superuser", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s chain, %q role", tt.chain, tt.role), func(t *testing.T) {
+			rr := serve(t, app, http.MethodGet, tt.path, "", app.testToken(t, 1, tt.role))
+			if rr.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rr.Code, tt.want, rr.Body)
+			}
+		})
+	}
+}
+
+func TestSetUserRole(t *testing.T) {
+	app := newTestApplication(t)
+	admin, _ := app.testLogin(t, "admin@example.com", models.RoleAdmin)
+	viewer, _ := app.testLogin(t, "viewer@example.com", models.RoleViewer)
+	token := app.testToken(t, admin.ID, models.RoleAdmin)
+
+	tests := []struct {
+		name string
+		id   int
+		body string
+		want int
+	}{
+		{"promote a viewer", viewer.ID, `{"role":"editor"}`, http.StatusOK},
+		{"unknown role", viewer.ID, `{"role":"superuser"}`, http.StatusUnprocessableEntity},
+		{"missing user", 999, `{"role":"editor"}`, http.StatusNotFound},
+		{"demote yourself", admin.ID, `{"role":"editor"}`, http.StatusConflict},
+		{"keep your own admin role", admin.ID, `{"role":"admin"}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serve(t, app, http.MethodPut, fmt.Sprintf("/v1/admin/users/%d/role", tt.id), tt.body, token)
+			if rr.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rr.Code, tt.want, rr.Body)
+			}
+		})
+	}
+
+	user, err := app.models.DB.UserByEmail(context.Background(), "viewer@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Role != models.RoleEditor {
+		t.Errorf("role = %q after the promotion, want editor", user.Role)
+	}
+}
@@ -2,12 +2,31 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/BradPreston/go-movies/backend/models"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
 )
 
-func (app *application) writeJSON(w http.ResponseWriter, status int, data interface{}, wrap string) error {
+// envelope holds extra top-level fields written next to the wrapped data
+type envelope map[string]interface{}
+
+func (app *application) writeJSON(w http.ResponseWriter, status int, data interface{}, wrap string, extra ...envelope) error {
 	wrapper := make(map[string]interface{})
 	wrapper[wrap] = data
+	for _, e := range extra {
+		for k, v := range e {
+			wrapper[k] = v
+		}
+	}
 
 	js, err := json.Marshal(wrapper)
 	if err != nil {
@@ -21,6 +40,8 @@ func (app *application) writeJSON(w http.ResponseWriter, status int, data interf
 	return nil
 }
 
+// errorJSON writes err as a JSON error with the given status, 400 if none is
+// given. Errors from the models package go through storeErrorJSON instead.
 func (app *application) errorJSON(w http.ResponseWriter, err error, status ...int) {
 	statusCode := http.StatusBadRequest
 	if len(status) > 0 {
@@ -36,3 +57,138 @@ func (app *application) errorJSON(w http.ResponseWriter, err error, status ...in
 
 	app.writeJSON(w, statusCode, theError, "error")
 }
+
+// storeErrorJSON writes an error returned by the store with the status code
+// of its kind. Other errors are logged and reported without their text, which
+// may contain SQL.
+func (app *application) storeErrorJSON(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, models.ErrNotFound):
+		app.errorJSON(w, err, http.StatusNotFound)
+	case errors.Is(err, models.ErrConflict):
+		app.errorJSON(w, err, http.StatusConflict)
+	case errors.Is(err, models.ErrValidation):
+		app.errorJSON(w, err, http.StatusUnprocessableEntity)
+	case errors.Is(err, models.ErrUnavailable):
+		app.logger.Println(models.Cause(err))
+		app.errorJSON(w, err, http.StatusServiceUnavailable)
+	default:
+		app.logger.Println(err)
+		app.errorJSON(w, errors.New("the server encountered a problem and could not process the request"), http.StatusInternalServerError)
+	}
+}
+
+// movieETag returns the entity tag of a movie, which changes with every edit
+func movieETag(movie *models.Movie) string {
+	return fmt.Sprintf(`"%d-%d"`, movie.ID, movie.Version)
+}
+
+// etagMatches reports whether an If-Match header value matches an entity tag
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatchVersion returns the version of a movie named by an If-Match header
+// holding one of the movie's ETags
+func ifMatchVersion(header string, id int) (int, bool) {
+	for _, candidate := range strings.Split(header, ",") {
+		var movieID, version int
+		if _, err := fmt.Sscanf(strings.TrimSpace(candidate), `"%d-%d"`, &movieID, &version); err == nil && movieID == id {
+			return version, true
+		}
+	}
+	return 0, false
+}
+
+// readPage reads the limit and cursor query parameters
+func (app *application) readPage(r *http.Request) (models.Page, error) {
+	limit, err := app.readLimit(r)
+	if err != nil {
+		return models.Page{}, err
+	}
+
+	return models.Page{Limit: limit, Cursor: r.URL.Query().Get("cursor")}, nil
+}
+
+// readLimit reads the limit query parameter, defaulting to defaultPageLimit
+func (app *application) readLimit(r *http.Request) (int, error) {
+	limit := r.URL.Query().Get("limit")
+	if limit == "" {
+		return defaultPageLimit, nil
+	}
+
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 1 || n > maxPageLimit {
+		return 0, errors.New("limit must be between 1 and " + strconv.Itoa(maxPageLimit))
+	}
+	return n, nil
+}
+
+// readMovieFilter reads the filter, sort and page query parameters of a
+// movie list request
+func (app *application) readMovieFilter(r *http.Request) (models.MovieFilter, error) {
+	filter, err := app.readUnpagedMovieFilter(r)
+	if err != nil {
+		return filter, err
+	}
+
+	filter.Page, err = app.readPage(r)
+	return filter, err
+}
+
+// readUnpagedMovieFilter reads the filter and sort query parameters of a
+// movie request that is not paged, such as an export. The store validates
+// the sort key and ranges.
+func (app *application) readUnpagedMovieFilter(r *http.Request) (models.MovieFilter, error) {
+	qs := r.URL.Query()
+
+	var filter models.MovieFilter
+	var err error
+
+	ints := map[string]*int{
+		"year_from":   &filter.YearFrom,
+		"year_to":     &filter.YearTo,
+		"min_rating":  &filter.MinRating,
+		"runtime_min": &filter.RuntimeMin,
+		"runtime_max": &filter.RuntimeMax,
+	}
+	for name, dest := range ints {
+		if v := qs.Get(name); v != "" {
+			if *dest, err = strconv.Atoi(v); err != nil || *dest < 0 {
+				return filter, errors.New(name + " must be a positive number")
+			}
+		}
+	}
+
+	if v := qs.Get("mpaa"); v != "" {
+		filter.MPAARating = strings.Split(v, ",")
+	}
+
+	if v := qs.Get("genre"); v != "" {
+		for _, g := range strings.Split(v, ",") {
+			id, err := strconv.Atoi(g)
+			if err != nil {
+				return filter, errors.New("genre must be a comma separated list of genre IDs")
+			}
+			filter.Genres = append(filter.Genres, id)
+		}
+	}
+
+	filter.Sort = qs.Get("sort")
+
+	return filter, nil
+}
+
+// pageMeta returns the envelope fields describing the next page
+func pageMeta(next string) envelope {
+	if next == "" {
+		return envelope{"next_cursor": nil}
+	}
+	return envelope{"next_cursor": next}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BradPreston/go-movies/backend/models"
+)
+
+func TestErrorJSONStatus(t *testing.T) {
+	app := newTestApplication(t)
+	validation := fmt.Errorf("checking: %w", models.ErrInvalidRole)
+
+	tests := []struct {
+		name  string
+		write func(w http.ResponseWriter)
+		want  int
+	}{
+		{"default", func(w http.ResponseWriter) { app.errorJSON(w, errors.New("bad")) }, http.StatusBadRequest},
+		{"explicit status", func(w http.ResponseWriter) { app.errorJSON(w, errors.New("bad"), http.StatusConflict) }, http.StatusConflict},
+		{"validation error is not rewritten", func(w http.ResponseWriter) { app.errorJSON(w, validation) }, http.StatusBadRequest},
+		{"store validation error", func(w http.ResponseWriter) { app.storeErrorJSON(w, validation) }, http.StatusUnprocessableEntity},
+		{"store not found", func(w http.ResponseWriter) { app.storeErrorJSON(w, models.ErrNotFound) }, http.StatusNotFound},
+		{"store conflict", func(w http.ResponseWriter) { app.storeErrorJSON(w, models.ErrEditConflict) }, http.StatusConflict},
+		{"unknown store error", func(w http.ResponseWriter) { app.storeErrorJSON(w, errors.New("pq: boom")) }, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			tt.write(rr)
+			if rr.Code != tt.want {
+				t.Errorf("status = %d, want %d", rr.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidationErrorsAre422(t *testing.T) {
+	app := newTestApplication(t)
+	editor := app.testToken(t, 1, models.RoleEditor)
+
+	tests := []struct {
+		name         string
+		method, path string
+		body, token  string
+	}{
+		{"unknown sort", http.MethodGet, "/v1/movies?sort=year", "", ""},
+		{"inverted year range", http.MethodGet, "/v1/movies?year_from=2000&year_to=1990", "", ""},
+		{"export with unknown sort", http.MethodGet, "/v1/admin/export?sort=-", "", editor},
+		{"register with invalid email", http.MethodPost, "/v1/register", `{"email":"nobody","password":"Correct-Horse-9x!"}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serve(t, app, tt.method, tt.path, tt.body, tt.token)
+			if rr.Code != http.StatusUnprocessableEntity {
+				t.Errorf("status = %d, want 422: %s", rr.Code, rr.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// insertAudit records an administrative change. It is called with the
+// transaction of the change so that both are committed or neither is.
+func insertAudit(ctx context.Context, q queryer, actor Actor, action, entity string, entityID int, before, after interface{}) error {
+	entry, err := newAuditEntry(actor, action, entity, entityID, before, after)
+	if err != nil {
+		return err
+	}
+
+	stmt := `
+	INSERT INTO
+		audit_log (actor_id, action, entity, entity_id, before, after, changes, request_id, created_at)
+	VALUES
+		($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err = q.ExecContext(ctx, stmt,
+		entry.ActorID,
+		entry.Action,
+		entry.Entity,
+		entry.EntityID,
+		nullJSON(entry.Before),
+		nullJSON(entry.After),
+		string(entry.Changes),
+		entry.RequestID,
+		entry.CreatedAt,
+	)
+
+	return err
+}
+
+// nullJSON passes a nil snapshot to the database as NULL. JSON is sent as a
+// string because pq sends []byte in binary format, which jsonb rejects.
+func nullJSON(js []byte) interface{} {
+	if js == nil {
+		return nil
+	}
+	return string(js)
+}
+
+// AuditLog returns one page of audit entries matching the filter, newest
+// first, the cursor of the next page (empty on the last page) and an error,
+// if any
+func (m *DBModel) AuditLog(ctx context.Context, filter AuditFilter) ([]*AuditEntry, string, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	var where []string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.ActorID > 0 {
+		where = append(where, "actor_id = "+arg(filter.ActorID))
+	}
+	if filter.Action != "" {
+		where = append(where, "action = "+arg(filter.Action))
+	}
+	if filter.Entity != "" {
+		where = append(where, "entity = "+arg(filter.Entity))
+	}
+	if filter.EntityID > 0 {
+		where = append(where, "entity_id = "+arg(filter.EntityID))
+	}
+	if !filter.From.IsZero() {
+		where = append(where, "created_at >= "+arg(filter.From))
+	}
+	if !filter.To.IsZero() {
+		where = append(where, "created_at < "+arg(filter.To))
+	}
+	if filter.Cursor != "" {
+		id, err := decodeIDCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", dbError(err)
+		}
+		where = append(where, "id < "+arg(id))
+	}
+
+	whereClause := ""
+	if len(where) > 0 {
+		whereClause = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	limit := ""
+	if filter.Limit > 0 {
+		limit = "LIMIT " + arg(filter.Limit+1)
+	}
+
+	query := fmt.Sprintf(`
+	SELECT
+		id, actor_id, action, entity, entity_id, before, after, changes, request_id, created_at
+	FROM
+		audit_log %s
+	ORDER BY
+		id DESC
+	%s
+	`, whereClause, limit)
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", dbError(err)
+	}
+	defer rows.Close()
+
+	var entries []*AuditEntry
+	for rows.Next() {
+		var entry AuditEntry
+		var before, after, changes []byte
+		err := rows.Scan(
+			&entry.ID,
+			&entry.ActorID,
+			&entry.Action,
+			&entry.Entity,
+			&entry.EntityID,
+			&before,
+			&after,
+			&changes,
+			&entry.RequestID,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, "", dbError(err)
+		}
+		entry.Before, entry.After, entry.Changes = before, after, changes
+
+		entries = append(entries, &entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", dbError(err)
+	}
+
+	next := ""
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+		next = encodeIDCursor(entries[len(entries)-1].ID)
+	}
+
+	return entries, next, nil
+}
@@ -0,0 +1,60 @@
+package models
+
+import "context"
+
+// audit records an administrative change. The caller must hold m.mu for
+// writing.
+func (m *MemoryModel) audit(actor Actor, action, entity string, entityID int, before, after interface{}) error {
+	entry, err := newAuditEntry(actor, action, entity, entityID, before, after)
+	if err != nil {
+		return err
+	}
+
+	entry.ID = m.nextAuditID
+	m.nextAuditID++
+	m.auditLog = append(m.auditLog, entry)
+
+	return nil
+}
+
+// AuditLog returns one page of audit entries matching the filter, newest
+// first, the cursor of the next page (empty on the last page) and an error,
+// if any
+func (m *MemoryModel) AuditLog(ctx context.Context, filter AuditFilter) ([]*AuditEntry, string, error) {
+	before := 0
+	if filter.Cursor != "" {
+		id, err := decodeIDCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		before = id
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var entries []*AuditEntry
+	for i := len(m.auditLog) - 1; i >= 0; i-- {
+		entry := m.auditLog[i]
+		if before > 0 && entry.ID >= before {
+			continue
+		}
+		if !filter.matches(entry) {
+			continue
+		}
+
+		e := *entry
+		entries = append(entries, &e)
+		if filter.Limit > 0 && len(entries) > filter.Limit {
+			break
+		}
+	}
+
+	next := ""
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+		next = encodeIDCursor(entries[len(entries)-1].ID)
+	}
+
+	return entries, next, nil
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// Audit actions recorded by the stores
+const (
+	ActionMovieCreate  = "movie.create"
+	ActionMovieUpdate  = "movie.update"
+	ActionMovieDelete  = "movie.delete"
+	ActionMovieRestore = "movie.restore"
+	ActionMoviePurge   = "movie.purge"
+	ActionGenreCreate  = "genre.create"
+	ActionGenreRename  = "genre.rename"
+	ActionGenreDelete  = "genre.delete"
+)
+
+// Actor identifies who made a change and the request it was made in
+type Actor struct {
+	UserID    int
+	RequestID string
+}
+
+// AuditEntry is the record of one administrative change
+type AuditEntry struct {
+	ID        int             `json:"id"`
+	ActorID   int             `json:"actor_id"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityID  int             `json:"entity_id"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	Changes   json.RawMessage `json:"changes"`
+	RequestID string          `json:"request_id"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditFilter narrows and pages the audit log, newest entries first. Zero
+// values leave a field unfiltered.
+type AuditFilter struct {
+	ActorID  int
+	Action   string
+	Entity   string
+	EntityID int
+	From     time.Time
+	To       time.Time
+	Page
+}
+
+// matches reports whether an entry passes the filter
+func (f AuditFilter) matches(e *AuditEntry) bool {
+	return (f.ActorID == 0 || e.ActorID == f.ActorID) &&
+		(f.Action == "" || e.Action == f.Action) &&
+		(f.Entity == "" || e.Entity == f.Entity) &&
+		(f.EntityID == 0 || e.EntityID == f.EntityID) &&
+		(f.From.IsZero() || !e.CreatedAt.Before(f.From)) &&
+		(f.To.IsZero() || e.CreatedAt.Before(f.To))
+}
+
+// fieldChange is the old and new value of one changed field
+type fieldChange struct {
+	From json.RawMessage `json:"from"`
+	To   json.RawMessage `json:"to"`
+}
+
+// newAuditEntry snapshots before and after as JSON and records which of their
+// top-level fields changed. Either side may be nil.
+func newAuditEntry(actor Actor, action, entity string, entityID int, before, after interface{}) (*AuditEntry, error) {
+	entry := AuditEntry{
+		ActorID:   actor.UserID,
+		Action:    action,
+		Entity:    entity,
+		EntityID:  entityID,
+		RequestID: actor.RequestID,
+		CreatedAt: time.Now(),
+	}
+
+	var err error
+	if entry.Before, err = marshalSnapshot(before); err != nil {
+		return nil, err
+	}
+	if entry.After, err = marshalSnapshot(after); err != nil {
+		return nil, err
+	}
+	if entry.Changes, err = diffJSON(entry.Before, entry.After); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// marshalSnapshot encodes v as JSON, leaving nil as nil
+func marshalSnapshot(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// diffJSON returns the top-level fields that differ between two JSON objects
+// as {"field": {"from": old, "to": new}}. A nil side counts as an empty object.
+func diffJSON(before, after json.RawMessage) (json.RawMessage, error) {
+	var b, a map[string]json.RawMessage
+	if before != nil {
+		if err := json.Unmarshal(before, &b); err != nil {
+			return nil, err
+		}
+	}
+	if after != nil {
+		if err := json.Unmarshal(after, &a); err != nil {
+			return nil, err
+		}
+	}
+
+	changes := make(map[string]fieldChange)
+	for field, to := range a {
+		from, ok := b[field]
+		if !ok || !bytes.Equal(from, to) {
+			changes[field] = fieldChange{From: from, To: to}
+		}
+	}
+	for field, from := range b {
+		if _, ok := a[field]; !ok {
+			changes[field] = fieldChange{From: from}
+		}
+	}
+
+	return json.Marshal(changes)
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/lib/pq"
+)
+
+// Error kinds. Every error returned by a MovieStore that is the caller's
+// fault or a known database condition matches one of these with errors.Is,
+// so that callers can react to the kind without knowing the backend.
+var (
+	// ErrNotFound is returned when the requested record does not exist
+	ErrNotFound = errors.New("record not found")
+	// ErrConflict is returned when a change clashes with the stored state
+	ErrConflict = errors.New("conflict with the current state of the record")
+	// ErrValidation is returned when input is rejected
+	ErrValidation = errors.New("validation failed")
+	// ErrUnavailable is returned when the database cannot be reached or
+	// did not answer in time
+	ErrUnavailable = errors.New("database unavailable")
+)
+
+var (
+	// ErrDuplicateGenre is returned when a genre name is already taken
+	ErrDuplicateGenre = newError(ErrConflict, "a genre with that name already exists")
+	// ErrGenreInUse is returned when deleting a genre still linked to movies
+	ErrGenreInUse = newError(ErrConflict, "genre is still assigned to movies")
+	// ErrInvalidGenre is returned when a movie is linked to a genre that does not exist
+	ErrInvalidGenre = newError(ErrValidation, "unknown genre")
+	// ErrEditConflict is returned when a movie was changed by someone else
+	// since the version being edited was read
+	ErrEditConflict = newError(ErrConflict, "movie was modified by another request")
+)
+
+// kindError is an error of one of the error kinds with a message that is
+// safe to show to clients. The cause, if any, is kept for logging only.
+type kindError struct {
+	kind  error
+	msg   string
+	cause error
+}
+
+func (e *kindError) Error() string {
+	return e.msg
+}
+
+func (e *kindError) Unwrap() error {
+	return e.kind
+}
+
+// newError returns an error of the given kind
+func newError(kind error, msg string) error {
+	return &kindError{kind: kind, msg: msg}
+}
+
+// validationErrorf returns a validation error with a formatted message
+func validationErrorf(format string, args ...interface{}) error {
+	return newError(ErrValidation, fmt.Sprintf(format, args...))
+}
+
+// Cause returns the underlying error of a translated database error, or err
+// itself. It is meant for logging: its text may contain SQL details.
+func Cause(err error) error {
+	var ke *kindError
+	if errors.As(err, &ke) && ke.cause != nil {
+		return ke.cause
+	}
+	return err
+}
+
+// dbError translates a database error into one of the error kinds. Errors
+// that already have a kind, and errors it does not recognise, are returned
+// unchanged.
+func dbError(err error) error {
+	var ke *kindError
+	switch {
+	case err == nil, errors.As(err, &ke):
+		return err
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrNotFound
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, driver.ErrBadConn),
+		errors.Is(err, sql.ErrConnDone):
+		return &kindError{kind: ErrUnavailable, msg: ErrUnavailable.Error(), cause: err}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return &kindError{kind: ErrUnavailable, msg: ErrUnavailable.Error(), cause: err}
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	switch {
+	case pqErr.Code == pqUniqueViolation:
+		return &kindError{kind: ErrConflict, msg: "a record with the same values already exists", cause: err}
+	case pqErr.Code == pqForeignKeyViolation:
+		return &kindError{kind: ErrConflict, msg: "the record references, or is referenced by, another record", cause: err}
+	case pqErr.Code.Class() == "40":
+		return &kindError{kind: ErrConflict, msg: "the record was changed concurrently, try again", cause: err}
+	case pqErr.Code.Class() == "22", pqErr.Code == pqNotNullViolation, pqErr.Code == pqCheckViolation:
+		return &kindError{kind: ErrValidation, msg: "invalid value", cause: err}
+	case pqErr.Code.Class() == "08", pqErr.Code.Class() == "53", pqErr.Code.Class() == "57":
+		return &kindError{kind: ErrUnavailable, msg: ErrUnavailable.Error(), cause: err}
+	}
+
+	return err
+}
+
+// isPQError reports whether err is a postgres error with the given code
+func isPQError(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
+
+const (
+	pqUniqueViolation     pq.ErrorCode = "23505"
+	pqForeignKeyViolation pq.ErrorCode = "23503"
+	pqNotNullViolation    pq.ErrorCode = "23502"
+	pqCheckViolation      pq.ErrorCode = "23514"
+)
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// exportBatchSize is the number of movies fetched from the export cursor at
+// a time
+const exportBatchSize = 500
+
+// ExportMovies calls fn for every movie matching the filter, in the order of
+// the filter's sort and ignoring its page. Movies are read through a
+// server-side cursor in batches of exportBatchSize, so the result set is
+// never held in memory. An error returned by fn stops the export.
+func (m *DBModel) ExportMovies(ctx context.Context, filter MovieFilter, fn func(*Movie) error) error {
+	filter.Page = Page{}
+	where, orderBy, args, err := movieFilterSQL(filter)
+	if err != nil {
+		return dbError(err)
+	}
+
+	tx, err := m.DB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return dbError(err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`
+	DECLARE movie_export NO SCROLL CURSOR FOR
+	SELECT
+		id, title, description, year, release_date, runtime, rating, mpaa_rating, created_at, updated_at, coalesce(poster, ''), version, audience_count, coalesce(round(audience_total::numeric / nullif(audience_count, 0), 2), 0),
+		coalesce((
+			SELECT
+				json_object_agg(mg.genre_id, g.genre_name)
+			FROM
+				movies_genres mg
+				JOIN genres g ON (g.id = mg.genre_id)
+			WHERE
+				mg.movie_id = movies.id
+		), '{}')
+	FROM
+		movies %s
+	ORDER BY
+		%s
+	`, where, orderBy)
+
+	if err = m.execTimeout(ctx, tx, query, args...); err != nil {
+		return dbError(err)
+	}
+
+	for {
+		batch, err := m.fetchExport(ctx, tx)
+		if err != nil {
+			return dbError(err)
+		}
+
+		for _, movie := range batch {
+			if err = fn(movie); err != nil {
+				return err
+			}
+		}
+
+		if len(batch) < exportBatchSize {
+			break
+		}
+	}
+
+	return dbError(tx.Commit())
+}
+
+// execTimeout runs a statement bounded by the model's timeout
+func (m *DBModel) execTimeout(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+// fetchExport reads the next batch of movies from the export cursor. The
+// rows are closed before the batch is handed on, so a slow client does not
+// count against the query timeout.
+func (m *DBModel) fetchExport(ctx context.Context, tx *sql.Tx) ([]*Movie, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("FETCH %d FROM movie_export", exportBatchSize))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	batch := make([]*Movie, 0, exportBatchSize)
+	for rows.Next() {
+		var movie Movie
+		var genres []byte
+		err := rows.Scan(
+			&movie.ID,
+			&movie.Title,
+			&movie.Description,
+			&movie.Year,
+			&movie.ReleaseDate,
+			&movie.Runtime,
+			&movie.Rating,
+			&movie.MPAARating,
+			&movie.CreatedAt,
+			&movie.UpdatedAt,
+			&movie.Poster,
+			&movie.Version,
+			&movie.AudienceCount,
+			&movie.AudienceRating,
+			&genres,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = json.Unmarshal(genres, &movie.MovieGenre); err != nil {
+			return nil, err
+		}
+
+		batch = append(batch, &movie)
+	}
+
+	return batch, rows.Err()
+}
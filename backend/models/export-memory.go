@@ -0,0 +1,22 @@
+package models
+
+import "context"
+
+// ExportMovies calls fn for every movie matching the filter, in the order of
+// the filter's sort and ignoring its page. An error returned by fn stops the
+// export.
+func (m *MemoryModel) ExportMovies(ctx context.Context, filter MovieFilter, fn func(*Movie) error) error {
+	filter.Page = Page{}
+	movies, _, err := m.All(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	for _, movie := range movies {
+		if err = fn(movie); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"strings"
+)
+
+// movieSortColumns whitelists the sort keys accepted by MovieFilter
+var movieSortColumns = map[string]string{
+	"title":        "title",
+	"release_date": "release_date",
+	"rating":       "rating",
+	"runtime":      "runtime",
+}
+
+// MovieFilter narrows, orders and pages a list of movies. Zero values leave
+// a field unfiltered.
+type MovieFilter struct {
+	YearFrom   int
+	YearTo     int
+	MinRating  int
+	MPAARating []string
+	RuntimeMin int
+	RuntimeMax int
+	// Genres matches movies linked to any of the listed genre IDs
+	Genres []int
+	// Sort is a sort key such as "rating", prefixed with "-" for descending
+	// order. It defaults to "title".
+	Sort string
+	Page
+}
+
+// Validate checks that the filter only uses whitelisted sort keys and
+// consistent ranges
+func (f MovieFilter) Validate() error {
+	// a bare "-" has an empty key, which is not in the whitelist either
+	if f.Sort != "" {
+		if _, ok := movieSortColumns[strings.TrimPrefix(f.Sort, "-")]; !ok {
+			return validationErrorf("invalid sort key %q", f.Sort)
+		}
+	}
+
+	if f.YearFrom > 0 && f.YearTo > 0 && f.YearFrom > f.YearTo {
+		return validationErrorf("year_from must not be after year_to")
+	}
+
+	if f.RuntimeMin > 0 && f.RuntimeMax > 0 && f.RuntimeMin > f.RuntimeMax {
+		return validationErrorf("runtime_min must not be above runtime_max")
+	}
+
+	return nil
+}
+
+// sortKey returns the sort key and whether the order is descending
+func (f MovieFilter) sortKey() (string, bool) {
+	if f.Sort == "" {
+		return "title", false
+	}
+	return strings.TrimPrefix(f.Sort, "-"), strings.HasPrefix(f.Sort, "-")
+}
+
+// matches reports whether a movie with the given genre IDs passes the filter
+func (f MovieFilter) matches(movie *Movie, genres []int) bool {
+	if f.YearFrom > 0 && movie.Year < f.YearFrom {
+		return false
+	}
+	if f.YearTo > 0 && movie.Year > f.YearTo {
+		return false
+	}
+	if f.MinRating > 0 && movie.Rating < f.MinRating {
+		return false
+	}
+	if f.RuntimeMin > 0 && movie.Runtime < f.RuntimeMin {
+		return false
+	}
+	if f.RuntimeMax > 0 && movie.Runtime > f.RuntimeMax {
+		return false
+	}
+
+	if len(f.MPAARating) > 0 {
+		found := false
+		for _, r := range f.MPAARating {
+			if movie.MPAARating == r {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(f.Genres) > 0 {
+		found := false
+		for _, want := range f.Genres {
+			for _, g := range genres {
+				if g == want {
+					found = true
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// compareMovies orders two movies by a sort key with the ID as tie-breaker
+func compareMovies(a, b *Movie, key string) int {
+	c := 0
+	switch key {
+	case "release_date":
+		switch {
+		case a.ReleaseDate.Before(b.ReleaseDate):
+			c = -1
+		case a.ReleaseDate.After(b.ReleaseDate):
+			c = 1
+		}
+	case "rating":
+		c = compareInts(a.Rating, b.Rating)
+	case "runtime":
+		c = compareInts(a.Runtime, b.Runtime)
+	default:
+		c = strings.Compare(a.Title, b.Title)
+	}
+
+	if c == 0 {
+		c = compareInts(a.ID, b.ID)
+	}
+
+	return c
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestMovieFilterRejectsUnknownSort(t *testing.T) {
+	sorts := []string{
+		"year",
+		"Title",
+		"--title",
+		"title DESC",
+		"title; DROP TABLE movies",
+		"title, (SELECT password_hash FROM users LIMIT 1)",
+		"1",
+		"-",
+		"id",
+	}
+
+	for _, sort := range sorts {
+		filter := MovieFilter{Sort: sort}
+		if err := filter.Validate(); !errors.Is(err, ErrValidation) {
+			t.Errorf("Validate with sort %q: got %v, want ErrValidation", sort, err)
+		}
+		if _, _, _, err := movieFilterSQL(filter); !errors.Is(err, ErrValidation) {
+			t.Errorf("movieFilterSQL with sort %q: got %v, want ErrValidation", sort, err)
+		}
+	}
+}
+
+func TestMovieFilterSQL(t *testing.T) {
+	after := &Movie{ID: 7, Title: "Heat", Rating: 4}
+
+	tests := []struct {
+		name    string
+		filter  MovieFilter
+		where   string
+		orderBy string
+		args    []interface{}
+	}{
+		{
+			name:    "no filter",
+			where:   "WHERE deleted_at IS NULL",
+			orderBy: "title ASC, id ASC",
+		},
+		{
+			name:    "year range",
+			filter:  MovieFilter{YearFrom: 1990, YearTo: 1999},
+			where:   "WHERE deleted_at IS NULL AND year >= $1 AND year <= $2",
+			orderBy: "title ASC, id ASC",
+			args:    []interface{}{1990, 1999},
+		},
+		{
+			name:    "minimum rating",
+			filter:  MovieFilter{MinRating: 4},
+			where:   "WHERE deleted_at IS NULL AND rating >= $1",
+			orderBy: "title ASC, id ASC",
+			args:    []interface{}{4},
+		},
+		{
+			name:    "mpaa ratings",
+			filter:  MovieFilter{MPAARating: []string{"PG", "R'); DROP TABLE movies; --"}},
+			where:   "WHERE deleted_at IS NULL AND mpaa_rating = ANY($1)",
+			orderBy: "title ASC, id ASC",
+			args:    []interface{}{pq.StringArray{"PG", "R'); DROP TABLE movies; --"}},
+		},
+		{
+			name:    "runtime range",
+			filter:  MovieFilter{RuntimeMin: 90, RuntimeMax: 120},
+			where:   "WHERE deleted_at IS NULL AND runtime >= $1 AND runtime <= $2",
+			orderBy: "title ASC, id ASC",
+			args:    []interface{}{90, 120},
+		},
+		{
+			name:    "genres",
+			filter:  MovieFilter{Genres: []int{1, 3}},
+			where:   "WHERE deleted_at IS NULL AND id IN (SELECT movie_id FROM movies_genres WHERE genre_id = ANY($1))",
+			orderBy: "title ASC, id ASC",
+			args:    []interface{}{pq.Int64Array{1, 3}},
+		},
+		{
+			name:    "descending sort",
+			filter:  MovieFilter{Sort: "-release_date"},
+			where:   "WHERE deleted_at IS NULL",
+			orderBy: "release_date DESC, id DESC",
+		},
+		{
+			name:    "ascending sort",
+			filter:  MovieFilter{Sort: "runtime"},
+			where:   "WHERE deleted_at IS NULL",
+			orderBy: "runtime ASC, id ASC",
+		},
+		{
+			name:    "cursor",
+			filter:  MovieFilter{Sort: "-rating", Page: Page{Cursor: encodeCursor(after, "rating")}},
+			where:   "WHERE deleted_at IS NULL AND (rating, id) < ($1, $2)",
+			orderBy: "rating DESC, id DESC",
+			args:    []interface{}{4, 7},
+		},
+		{
+			name:    "every filter",
+			filter:  MovieFilter{YearFrom: 1990, MinRating: 3, RuntimeMax: 150, Genres: []int{2}, Sort: "title"},
+			where:   "WHERE deleted_at IS NULL AND year >= $1 AND rating >= $2 AND runtime <= $3 AND id IN (SELECT movie_id FROM movies_genres WHERE genre_id = ANY($4))",
+			orderBy: "title ASC, id ASC",
+			args:    []interface{}{1990, 3, 150, pq.Int64Array{2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, orderBy, args, err := movieFilterSQL(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if where != tt.where {
+				t.Errorf("where = %q, want %q", where, tt.where)
+			}
+			if orderBy != tt.orderBy {
+				t.Errorf("order by = %q, want %q", orderBy, tt.orderBy)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %#v, want %#v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestMovieFilterSQLRejectsCursorOfAnotherSort(t *testing.T) {
+	cursor := encodeCursor(&Movie{ID: 7, Title: "Heat"}, "title")
+
+	filter := MovieFilter{Sort: "rating", Page: Page{Cursor: cursor}}
+	if _, _, _, err := movieFilterSQL(filter); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("got %v, want ErrInvalidCursor", err)
+	}
+}
@@ -0,0 +1,348 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ImportMovies creates, or with opts.Upsert updates, many movies at once.
+// Rows that cannot be imported are reported in the result; in atomic mode a
+// single failed row means nothing is imported. New movies are inserted in
+// batches of importBatchSize, each batch in its own transaction unless the
+// import is atomic. When a batch fails its rows are retried one at a time so
+// that only the offending rows are reported.
+func (m *DBModel) ImportMovies(ctx context.Context, rows []ImportRow, opts ImportOptions, actor Actor) (*ImportResult, error) {
+	result := &ImportResult{}
+
+	genres, existing, err := m.importLookups(ctx, rows, opts.Upsert)
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	var inserts, updates []ImportRow
+	updateIDs := make(map[int]int)
+	seen := make(map[string]int)
+	for _, row := range rows {
+		if !hasGenres(genres, row.Movie.GenreIDs()) {
+			result.Fail(row.Row, ErrInvalidGenre)
+			continue
+		}
+		if !opts.Upsert {
+			inserts = append(inserts, row)
+			continue
+		}
+
+		key := importKey(row.Movie.Title, row.Movie.Year)
+		if prev, ok := seen[key]; ok {
+			result.Fail(row.Row, validationErrorf("duplicate of row %d", prev))
+			continue
+		}
+		seen[key] = row.Row
+
+		if id, ok := existing[key]; ok {
+			updateIDs[row.Row] = id
+			updates = append(updates, row)
+		} else {
+			inserts = append(inserts, row)
+		}
+	}
+	if opts.Atomic && result.Failed > 0 {
+		return result, nil
+	}
+
+	if opts.Atomic {
+		batches := len(inserts)/importBatchSize + len(updates) + 1
+		ctx, cancel := context.WithTimeout(ctx, m.timeout()*time.Duration(batches))
+		defer cancel()
+
+		var summary importSummary
+		err := m.withTx(ctx, func(tx *sql.Tx) error {
+			for _, batch := range importBatches(inserts) {
+				ids, err := insertMovieBatch(ctx, tx, batch)
+				if err != nil {
+					return err
+				}
+				summary.Inserted = append(summary.Inserted, ids...)
+			}
+			for _, row := range updates {
+				if err := importUpdate(ctx, tx, updateIDs[row.Row], row.Movie, actor); err != nil {
+					return err
+				}
+				summary.Updated = append(summary.Updated, updateIDs[row.Row])
+			}
+			return insertImportAudit(ctx, tx, actor, summary)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		result.Inserted, result.Updated = len(summary.Inserted), len(summary.Updated)
+		return result, nil
+	}
+
+	for _, batch := range importBatches(inserts) {
+		n, err := m.importBatch(ctx, batch, actor)
+		if err == nil {
+			result.Inserted += n
+			continue
+		}
+		if errors.Is(err, ErrUnavailable) {
+			return nil, err
+		}
+
+		for _, row := range batch {
+			n, err := m.importBatch(ctx, []ImportRow{row}, actor)
+			if err != nil {
+				result.Fail(row.Row, err)
+				continue
+			}
+			result.Inserted += n
+		}
+	}
+
+	for _, row := range updates {
+		id := updateIDs[row.Row]
+		ctx, cancel := m.withTimeout(ctx)
+		err := m.withTx(ctx, func(tx *sql.Tx) error {
+			if err := importUpdate(ctx, tx, id, row.Movie, actor); err != nil {
+				return err
+			}
+			return insertImportAudit(ctx, tx, actor, importSummary{Updated: []int{id}})
+		})
+		cancel()
+		if err != nil {
+			result.Fail(row.Row, err)
+			continue
+		}
+		result.Updated++
+	}
+
+	return result, nil
+}
+
+// importBatch inserts a batch of movies in its own transaction and returns
+// how many were inserted
+func (m *DBModel) importBatch(ctx context.Context, batch []ImportRow, actor Actor) (int, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	var ids []int
+	err := m.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		if ids, err = insertMovieBatch(ctx, tx, batch); err != nil {
+			return err
+		}
+		return insertImportAudit(ctx, tx, actor, importSummary{Inserted: ids})
+	})
+
+	return len(ids), err
+}
+
+// importLookups returns the IDs of all genres and, for upserts, the IDs of
+// the movies that rows would update, keyed by importKey
+func (m *DBModel) importLookups(ctx context.Context, rows []ImportRow, upsert bool) (map[int]bool, map[string]int, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	genres := make(map[int]bool)
+	genreRows, err := m.DB.QueryContext(ctx, "SELECT id FROM genres")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer genreRows.Close()
+
+	for genreRows.Next() {
+		var id int
+		if err = genreRows.Scan(&id); err != nil {
+			return nil, nil, err
+		}
+		genres[id] = true
+	}
+	if err = genreRows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	existing := make(map[string]int)
+	if !upsert || len(rows) == 0 {
+		return genres, existing, nil
+	}
+
+	titles := make([]string, len(rows))
+	for i, row := range rows {
+		titles[i] = strings.ToLower(row.Movie.Title)
+	}
+
+	query := `
+	SELECT
+		id, title, year
+	FROM
+		movies
+	WHERE
+		deleted_at IS NULL AND lower(title) = ANY($1)
+	ORDER BY
+		id
+	`
+
+	movieRows, err := m.DB.QueryContext(ctx, query, pq.StringArray(titles))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer movieRows.Close()
+
+	for movieRows.Next() {
+		var id, year int
+		var title string
+		if err = movieRows.Scan(&id, &title, &year); err != nil {
+			return nil, nil, err
+		}
+		key := importKey(title, year)
+		if _, ok := existing[key]; !ok {
+			existing[key] = id
+		}
+	}
+
+	return genres, existing, movieRows.Err()
+}
+
+// hasGenres reports whether every genre ID is in the set
+func hasGenres(set map[int]bool, ids []int) bool {
+	for _, id := range ids {
+		if !set[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// insertMovieBatch inserts movies and their genre links with one statement
+// each and returns the new IDs in the order of the rows
+func insertMovieBatch(ctx context.Context, tx *sql.Tx, rows []ImportRow) ([]int, error) {
+	n := len(rows)
+	titles, descriptions, dates, ratings, posters := make([]string, n), make([]string, n), make([]string, n), make([]string, n), make([]string, n)
+	years, runtimes, scores := make([]int64, n), make([]int64, n), make([]int64, n)
+	for i, row := range rows {
+		titles[i] = row.Movie.Title
+		descriptions[i] = row.Movie.Description
+		years[i] = int64(row.Movie.Year)
+		dates[i] = row.Movie.ReleaseDate.Format("2006-01-02")
+		runtimes[i] = int64(row.Movie.Runtime)
+		scores[i] = int64(row.Movie.Rating)
+		ratings[i] = row.Movie.MPAARating
+		posters[i] = row.Movie.Poster
+	}
+
+	// serial IDs are handed out in the order of the ORDER BY, so sorting the
+	// returned IDs lines them up with the rows
+	stmt := `
+	INSERT INTO
+		movies (title, description, year, release_date, runtime, rating, mpaa_rating, created_at, updated_at, poster)
+	SELECT
+		title, description, year, release_date, runtime, rating, mpaa_rating, $9, $9, poster
+	FROM
+		unnest($1::text[], $2::text[], $3::integer[], $4::date[], $5::integer[], $6::integer[], $7::text[], $8::text[])
+		WITH ORDINALITY AS t (title, description, year, release_date, runtime, rating, mpaa_rating, poster, n)
+	ORDER BY
+		n
+	RETURNING
+		id
+	`
+
+	result, err := tx.QueryContext(ctx, stmt,
+		pq.StringArray(titles),
+		pq.StringArray(descriptions),
+		pq.Int64Array(years),
+		pq.StringArray(dates),
+		pq.Int64Array(runtimes),
+		pq.Int64Array(scores),
+		pq.StringArray(ratings),
+		pq.StringArray(posters),
+		time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	ids := make([]int, 0, n)
+	for result.Next() {
+		var id int
+		if err = result.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err = result.Err(); err != nil {
+		return nil, err
+	}
+	sort.Ints(ids)
+
+	var movieIDs, genreIDs []int64
+	for i, row := range rows {
+		for _, genreID := range row.Movie.GenreIDs() {
+			movieIDs = append(movieIDs, int64(ids[i]))
+			genreIDs = append(genreIDs, int64(genreID))
+		}
+	}
+	if len(movieIDs) == 0 {
+		return ids, nil
+	}
+
+	stmt = `
+	INSERT INTO
+		movies_genres (movie_id, genre_id, created_at, updated_at)
+	SELECT
+		movie_id, genre_id, now(), now()
+	FROM
+		unnest($1::integer[], $2::integer[]) AS t (movie_id, genre_id)
+	`
+	if _, err = tx.ExecContext(ctx, stmt, pq.Int64Array(movieIDs), pq.Int64Array(genreIDs)); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// importUpdate overwrites an existing movie with an imported row, keeping the
+// previous state as a revision
+func importUpdate(ctx context.Context, tx *sql.Tx, id int, movie Movie, actor Actor) error {
+	before, err := getMovie(ctx, tx, id, true)
+	if err != nil {
+		return err
+	}
+	if before.DeletedAt != nil {
+		return ErrNotFound
+	}
+
+	if err = insertRevision(ctx, tx, before, ActionMovieUpdate, actor); err != nil {
+		return err
+	}
+
+	movie.ID = id
+	movie.DeletedAt = nil
+	keepUnsupplied(&movie, before)
+	if err = updateMovie(ctx, tx, movie); err != nil {
+		return err
+	}
+
+	after, err := getMovie(ctx, tx, id, false)
+	if err != nil {
+		return err
+	}
+
+	return insertAudit(ctx, tx, actor, ActionMovieUpdate, "movie", id, before, after)
+}
+
+// insertImportAudit records the movies created and updated by one
+// transaction of an import
+func insertImportAudit(ctx context.Context, tx *sql.Tx, actor Actor, summary importSummary) error {
+	if len(summary.Inserted)+len(summary.Updated) == 0 {
+		return nil
+	}
+	return insertAudit(ctx, tx, actor, ActionMovieImport, "movie", 0, nil, summary)
+}
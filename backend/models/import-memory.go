@@ -0,0 +1,89 @@
+package models
+
+import (
+	"context"
+)
+
+// ImportMovies creates, or with opts.Upsert updates, many movies at once.
+// Rows that cannot be imported are reported in the result; in atomic mode a
+// single failed row means nothing is imported.
+func (m *MemoryModel) ImportMovies(ctx context.Context, rows []ImportRow, opts ImportOptions, actor Actor) (*ImportResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := &ImportResult{}
+
+	existing := make(map[string]*Movie)
+	if opts.Upsert {
+		for _, movie := range m.movies {
+			if movie.DeletedAt == nil {
+				existing[importKey(movie.Title, movie.Year)] = movie
+			}
+		}
+	}
+
+	failed := make(map[int]bool)
+	seen := make(map[string]int)
+	for i, row := range rows {
+		if err := m.checkGenres(row.Movie.GenreIDs()); err != nil {
+			result.Fail(row.Row, err)
+			failed[i] = true
+			continue
+		}
+		if opts.Upsert {
+			key := importKey(row.Movie.Title, row.Movie.Year)
+			if prev, ok := seen[key]; ok {
+				result.Fail(row.Row, validationErrorf("duplicate of row %d", prev))
+				failed[i] = true
+				continue
+			}
+			seen[key] = row.Row
+		}
+	}
+	if opts.Atomic && result.Failed > 0 {
+		return result, nil
+	}
+
+	var summary importSummary
+	for i, row := range rows {
+		if failed[i] {
+			continue
+		}
+		movie := row.Movie
+
+		if current, ok := existing[importKey(movie.Title, movie.Year)]; ok {
+			before := m.copyMovie(current)
+			m.revise(before, ActionMovieUpdate, actor)
+			keepUnsupplied(&movie, before)
+			assignMovie(current, movie)
+			current.UpdatedAt = movie.UpdatedAt
+			current.Version++
+			m.replaceGenres(current.ID, movie.GenreIDs())
+			if err := m.audit(actor, ActionMovieUpdate, "movie", current.ID, before, m.copyMovie(current)); err != nil {
+				return nil, err
+			}
+			summary.Updated = append(summary.Updated, current.ID)
+			continue
+		}
+
+		genreIDs := movie.GenreIDs()
+		movie.ID = m.nextMovieID
+		movie.MovieGenre = nil
+		movie.Version = 1
+		m.nextMovieID++
+		m.movies[movie.ID] = &movie
+		m.replaceGenres(movie.ID, genreIDs)
+		summary.Inserted = append(summary.Inserted, movie.ID)
+	}
+
+	result.Inserted, result.Updated = len(summary.Inserted), len(summary.Updated)
+	if result.Inserted+result.Updated == 0 {
+		return result, nil
+	}
+
+	if err := m.audit(actor, ActionMovieImport, "movie", 0, nil, summary); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ActionMovieImport is the audit action of a bulk import. One entry is
+// recorded per transaction of an import, listing the IDs of the movies it
+// created and updated.
+const ActionMovieImport = "movie.import"
+
+// importBatchSize is the number of movies inserted per statement
+const importBatchSize = 500
+
+// ImportOptions control how ImportMovies treats the rows it is given
+type ImportOptions struct {
+	// Atomic imports every row or none of them
+	Atomic bool
+	// Upsert updates the movie with the same title (ignoring case) and
+	// year instead of creating a duplicate
+	Upsert bool
+}
+
+// ImportRow is one movie to import and its 1-based position in the source
+// file, used in error reports
+type ImportRow struct {
+	Row   int
+	Movie Movie
+}
+
+// ImportError is the reason one row was not imported
+type ImportError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// ImportResult summarises a bulk import
+type ImportResult struct {
+	Inserted int           `json:"inserted"`
+	Updated  int           `json:"updated"`
+	Failed   int           `json:"failed"`
+	Errors   []ImportError `json:"errors"`
+}
+
+// Fail records a row that was not imported. Errors without a kind are
+// reported generically, since their text may contain SQL.
+func (r *ImportResult) Fail(row int, err error) {
+	msg := "the row could not be imported"
+	for _, kind := range []error{ErrNotFound, ErrConflict, ErrValidation, ErrUnavailable} {
+		if errors.Is(err, kind) {
+			msg = err.Error()
+		}
+	}
+
+	r.Failed++
+	r.Errors = append(r.Errors, ImportError{Row: row, Error: msg})
+}
+
+// importBatches splits rows into batches of at most importBatchSize
+func importBatches(rows []ImportRow) [][]ImportRow {
+	var batches [][]ImportRow
+	for len(rows) > importBatchSize {
+		batches = append(batches, rows[:importBatchSize])
+		rows = rows[importBatchSize:]
+	}
+	if len(rows) > 0 {
+		batches = append(batches, rows)
+	}
+	return batches
+}
+
+// importKey identifies a movie for upserts
+func importKey(title string, year int) string {
+	return strings.ToLower(title) + "\x00" + strconv.Itoa(year)
+}
+
+// importSummary is the audit snapshot of an import
+type importSummary struct {
+	Inserted []int `json:"inserted"`
+	Updated  []int `json:"updated"`
+}
+
+// keepUnsupplied copies the poster and genres of the stored movie into an
+// imported one that does not supply them, so that an upsert only changes
+// what the import file holds. Imports never carry a poster, and a nil
+// MovieGenre means the row had no genre_ids.
+func keepUnsupplied(movie *Movie, stored *Movie) {
+	if movie.Poster == "" {
+		movie.Poster = stored.Poster
+	}
+	if movie.MovieGenre == nil {
+		movie.MovieGenre = stored.MovieGenre
+	}
+}
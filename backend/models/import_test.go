@@ -0,0 +1,107 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestImportUpsertKeepsPosterAndGenres(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryModel()
+
+	genre, err := m.InsertGenre(ctx, "Drama", Actor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	release := time.Date(1994, 9, 23, 0, 0, 0, 0, time.UTC)
+	id, err := m.InsertMovie(ctx, Movie{
+		Title:       "The Shawshank Redemption",
+		Year:        1994,
+		ReleaseDate: release,
+		Runtime:     142,
+		Rating:      5,
+		Poster:      "/poster.jpg",
+		MovieGenre:  map[int]string{genre.ID: ""},
+	}, Actor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a CSV row without a genre_ids column
+	row := ImportRow{Row: 1, Movie: Movie{
+		Title:       "The Shawshank Redemption",
+		Description: "Imported description",
+		Year:        1994,
+		ReleaseDate: release,
+		Runtime:     144,
+		Rating:      4,
+	}}
+
+	result, err := m.ImportMovies(ctx, []ImportRow{row}, ImportOptions{Upsert: true}, Actor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Updated != 1 || result.Inserted != 0 || result.Failed != 0 {
+		t.Fatalf("got %+v, want one updated movie", result)
+	}
+
+	movie, err := m.Get(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if movie.Runtime != 144 || movie.Description != "Imported description" {
+		t.Errorf("imported fields were not applied: runtime %d, description %q", movie.Runtime, movie.Description)
+	}
+	if movie.Poster != "/poster.jpg" {
+		t.Errorf("poster = %q, want it kept", movie.Poster)
+	}
+	if len(movie.MovieGenre) != 1 || movie.MovieGenre[genre.ID] != "Drama" {
+		t.Errorf("genres = %v, want them kept", movie.MovieGenre)
+	}
+}
+
+func TestImportUpsertReplacesSuppliedGenres(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryModel()
+
+	drama, err := m.InsertGenre(ctx, "Drama", Actor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	crime, err := m.InsertGenre(ctx, "Crime", Actor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	release := time.Date(1972, 3, 24, 0, 0, 0, 0, time.UTC)
+	id, err := m.InsertMovie(ctx, Movie{
+		Title:       "The Godfather",
+		Year:        1972,
+		ReleaseDate: release,
+		MovieGenre:  map[int]string{drama.ID: ""},
+	}, Actor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row := ImportRow{Row: 1, Movie: Movie{
+		Title:       "The Godfather",
+		Year:        1972,
+		ReleaseDate: release,
+		MovieGenre:  map[int]string{crime.ID: ""},
+	}}
+
+	if _, err = m.ImportMovies(ctx, []ImportRow{row}, ImportOptions{Upsert: true}, Actor{}); err != nil {
+		t.Fatal(err)
+	}
+
+	movie, err := m.Get(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(movie.MovieGenre) != 1 || movie.MovieGenre[crime.ID] != "Crime" {
+		t.Errorf("genres = %v, want only Crime", movie.MovieGenre)
+	}
+}
@@ -0,0 +1,212 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ListMovies returns one page of the movies on one of a user's lists, most
+// recently added first, the cursor of the next page (empty on the last
+// page) and an error, if any. Movies in the trash are left out.
+func (m *DBModel) ListMovies(ctx context.Context, userID int, list string, page Page) ([]*Movie, string, error) {
+	if err := checkList(list); err != nil {
+		return nil, "", err
+	}
+
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	args := []interface{}{userID, list}
+	where := "WHERE l.user_id = $1 AND l.list = $2 AND m.deleted_at IS NULL"
+	if page.Cursor != "" {
+		before, err := decodeIDCursor(page.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		args = append(args, before)
+		where += " AND l.id < $3"
+	}
+
+	limit := ""
+	if page.Limit > 0 {
+		args = append(args, page.Limit+1)
+		limit = fmt.Sprintf("LIMIT $%d", len(args))
+	}
+
+	query := fmt.Sprintf(`
+	SELECT
+		l.id, m.id, m.title, m.description, m.year, m.release_date, m.runtime, m.rating, m.mpaa_rating, m.created_at, m.updated_at, coalesce(m.poster, ''), m.version, m.audience_count, coalesce(round(m.audience_total::numeric / nullif(m.audience_count, 0), 2), 0)
+	FROM
+		user_lists l
+		JOIN movies m ON m.id = l.movie_id
+	%s
+	ORDER BY
+		l.id DESC
+	%s
+	`, where, limit)
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", dbError(err)
+	}
+	defer rows.Close()
+
+	var movies []*Movie
+	var entries []int
+	for rows.Next() {
+		var movie Movie
+		var entry int
+		err := rows.Scan(
+			&entry,
+			&movie.ID,
+			&movie.Title,
+			&movie.Description,
+			&movie.Year,
+			&movie.ReleaseDate,
+			&movie.Runtime,
+			&movie.Rating,
+			&movie.MPAARating,
+			&movie.CreatedAt,
+			&movie.UpdatedAt,
+			&movie.Poster,
+			&movie.Version,
+			&movie.AudienceCount,
+			&movie.AudienceRating,
+		)
+		if err != nil {
+			return nil, "", dbError(err)
+		}
+
+		movies = append(movies, &movie)
+		entries = append(entries, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", dbError(err)
+	}
+	rows.Close()
+
+	next := ""
+	if page.Limit > 0 && len(movies) > page.Limit {
+		movies = movies[:page.Limit]
+		next = encodeIDCursor(entries[page.Limit-1])
+	}
+
+	ids := make([]int, len(movies))
+	for i, movie := range movies {
+		ids[i] = movie.ID
+	}
+
+	genres, err := genresFor(ctx, m.DB, ids)
+	if err != nil {
+		return nil, "", dbError(err)
+	}
+
+	for _, movie := range movies {
+		movie.MovieGenre = genres[movie.ID]
+	}
+
+	return movies, next, nil
+}
+
+// AddToList puts a movie that is not in the trash on one of a user's lists.
+// Adding a movie that is already on the list does nothing.
+func (m *DBModel) AddToList(ctx context.Context, userID int, list string, movieID int) error {
+	if err := checkList(list); err != nil {
+		return err
+	}
+
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	stmt := `
+	INSERT INTO
+		user_lists (user_id, list, movie_id, created_at)
+	SELECT
+		$1, $2, id, $4
+	FROM
+		movies
+	WHERE
+		id = $3 AND deleted_at IS NULL
+	ON CONFLICT (user_id, list, movie_id) DO NOTHING
+	RETURNING
+		id
+	`
+
+	var id int
+	err := m.DB.QueryRowContext(ctx, stmt, userID, list, movieID, time.Now()).Scan(&id)
+	if err == nil {
+		return nil
+	}
+	if err = dbError(err); err != ErrNotFound {
+		return err
+	}
+
+	// nothing was inserted: either the movie is already on the list or it
+	// does not exist
+	var exists bool
+	err = m.DB.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM user_lists WHERE user_id = $1 AND list = $2 AND movie_id = $3)", userID, list, movieID).Scan(&exists)
+	if err != nil {
+		return dbError(err)
+	}
+	if !exists {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// RemoveFromList takes a movie off one of a user's lists. Removing a movie
+// that is not on the list does nothing.
+func (m *DBModel) RemoveFromList(ctx context.Context, userID int, list string, movieID int) error {
+	if err := checkList(list); err != nil {
+		return err
+	}
+
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, "DELETE FROM user_lists WHERE user_id = $1 AND list = $2 AND movie_id = $3", userID, list, movieID)
+	return dbError(err)
+}
+
+// InWatchlist reports which of the given movies are on a user's watchlist
+func (m *DBModel) InWatchlist(ctx context.Context, userID int, movieIDs []int) (map[int]bool, error) {
+	found := make(map[int]bool)
+	if len(movieIDs) == 0 {
+		return found, nil
+	}
+
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		movie_id
+	FROM
+		user_lists
+	WHERE
+		user_id = $1 AND list = $2 AND movie_id = ANY($3)
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, ListWatchlist, pq.Array(movieIDs))
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, dbError(err)
+		}
+		found[id] = true
+	}
+	if err = rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return found, nil
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// listEntry is a movie on one of a user's lists
+type listEntry struct {
+	ID        int
+	UserID    int
+	List      string
+	MovieID   int
+	CreatedAt time.Time
+}
+
+// ListMovies returns one page of the movies on one of a user's lists, most
+// recently added first, the cursor of the next page (empty on the last
+// page) and an error, if any. Movies in the trash are left out.
+func (m *MemoryModel) ListMovies(ctx context.Context, userID int, list string, page Page) ([]*Movie, string, error) {
+	if err := checkList(list); err != nil {
+		return nil, "", err
+	}
+
+	before := 0
+	if page.Cursor != "" {
+		id, err := decodeIDCursor(page.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		before = id
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var movies []*Movie
+	var entries []int
+	// entries are kept in the order they were added
+	for i := len(m.lists) - 1; i >= 0; i-- {
+		entry := m.lists[i]
+		if entry.UserID != userID || entry.List != list || (before > 0 && entry.ID >= before) {
+			continue
+		}
+		movie, ok := m.movies[entry.MovieID]
+		if !ok || movie.DeletedAt != nil {
+			continue
+		}
+		movies = append(movies, m.copyMovie(movie))
+		entries = append(entries, entry.ID)
+	}
+
+	next := ""
+	if page.Limit > 0 && len(movies) > page.Limit {
+		movies = movies[:page.Limit]
+		next = encodeIDCursor(entries[page.Limit-1])
+	}
+
+	return movies, next, nil
+}
+
+// AddToList puts a movie that is not in the trash on one of a user's lists.
+// Adding a movie that is already on the list does nothing.
+func (m *MemoryModel) AddToList(ctx context.Context, userID int, list string, movieID int) error {
+	if err := checkList(list); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.findListEntry(userID, list, movieID) >= 0 {
+		return nil
+	}
+	if movie, ok := m.movies[movieID]; !ok || movie.DeletedAt != nil {
+		return ErrNotFound
+	}
+
+	m.lists = append(m.lists, &listEntry{
+		ID:        m.nextListID,
+		UserID:    userID,
+		List:      list,
+		MovieID:   movieID,
+		CreatedAt: time.Now(),
+	})
+	m.nextListID++
+
+	return nil
+}
+
+// RemoveFromList takes a movie off one of a user's lists. Removing a movie
+// that is not on the list does nothing.
+func (m *MemoryModel) RemoveFromList(ctx context.Context, userID int, list string, movieID int) error {
+	if err := checkList(list); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if i := m.findListEntry(userID, list, movieID); i >= 0 {
+		m.lists = append(m.lists[:i], m.lists[i+1:]...)
+	}
+
+	return nil
+}
+
+// InWatchlist reports which of the given movies are on a user's watchlist
+func (m *MemoryModel) InWatchlist(ctx context.Context, userID int, movieIDs []int) (map[int]bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	found := make(map[int]bool)
+	for _, id := range movieIDs {
+		if m.findListEntry(userID, ListWatchlist, id) >= 0 {
+			found[id] = true
+		}
+	}
+
+	return found, nil
+}
+
+// findListEntry returns the index of a movie on a user's list, or -1. The
+// caller must hold m.mu.
+func (m *MemoryModel) findListEntry(userID int, list string, movieID int) int {
+	for i, entry := range m.lists {
+		if entry.UserID == userID && entry.List == list && entry.MovieID == movieID {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeListEntries takes a movie off every list. The caller must hold m.mu.
+func (m *MemoryModel) removeListEntries(movieID int) {
+	entries := m.lists[:0]
+	for _, entry := range m.lists {
+		if entry.MovieID != movieID {
+			entries = append(entries, entry)
+		}
+	}
+	m.lists = entries
+}
@@ -0,0 +1,18 @@
+package models
+
+// The lists of movies every user has
+const (
+	ListWatchlist  = "watchlist"
+	ListFavourites = "favourites"
+)
+
+// ErrInvalidList is returned for a list name other than ListWatchlist and ListFavourites
+var ErrInvalidList = newError(ErrValidation, "unknown list")
+
+// checkList returns ErrInvalidList for an unknown list name
+func checkList(list string) error {
+	if list != ListWatchlist && list != ListFavourites {
+		return ErrInvalidList
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ReserveLogin counts a login attempt at the given time under a key as
+// failed before its password is checked, so that concurrent attempts are
+// counted one after another. wait is called with the failures counted so far
+// and, if it returns more than 0, the attempt is not counted and the wait is
+// returned. Failures before since are forgotten.
+func (m *DBModel) ReserveLogin(ctx context.Context, key string, at, since time.Time, wait func(*LoginAttempt) time.Duration) (time.Duration, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	var d time.Duration
+	err := m.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM login_attempts WHERE last_failure < $1", since); err != nil {
+			return err
+		}
+
+		stmt := `
+		INSERT INTO
+			login_attempts (key, failures, last_failure)
+		VALUES
+			($1, 0, $2)
+		ON CONFLICT (key) DO NOTHING
+		`
+		if _, err := tx.ExecContext(ctx, stmt, key, at); err != nil {
+			return err
+		}
+
+		// the row lock makes other attempts under the key wait for this one
+		a := LoginAttempt{Key: key}
+		err := tx.QueryRowContext(ctx, "SELECT failures, last_failure FROM login_attempts WHERE key = $1 FOR UPDATE", key).
+			Scan(&a.Failures, &a.LastFailure)
+		if err != nil {
+			return err
+		}
+
+		if d = wait(&a); d > 0 {
+			return nil
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE login_attempts SET failures = failures + 1, last_failure = $2 WHERE key = $1", key, at)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return d, nil
+}
+
+// ReleaseLogin takes back one attempt reserved under a key, for a login
+// that turned out not to fail
+func (m *DBModel) ReleaseLogin(ctx context.Context, key string) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, "UPDATE login_attempts SET failures = failures - 1 WHERE key = $1 AND failures > 0", key)
+	return dbError(err)
+}
+
+// ClearLoginAttempts forgets the failed logins counted under the given keys
+func (m *DBModel) ClearLoginAttempts(ctx context.Context, keys ...string) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, "DELETE FROM login_attempts WHERE key = ANY($1)", pq.Array(keys))
+	return dbError(err)
+}
+
+// UnlockUser forgets the failed logins to a user's email address, lifting
+// any lockout of the account
+func (m *DBModel) UnlockUser(ctx context.Context, id int, actor Actor) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		user, err := getUser(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		var failures int
+		err = tx.QueryRowContext(ctx, "DELETE FROM login_attempts WHERE key = $1 RETURNING failures", AccountLoginKey(user.Email)).Scan(&failures)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+
+		return insertAudit(ctx, tx, actor, ActionUserUnlock, "user", id, map[string]int{"failed_logins": failures}, nil)
+	})
+}
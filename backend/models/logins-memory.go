@@ -0,0 +1,84 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// ReserveLogin counts a login attempt at the given time under a key as
+// failed before its password is checked, so that concurrent attempts are
+// counted one after another. wait is called with the failures counted so far
+// and, if it returns more than 0, the attempt is not counted and the wait is
+// returned. Failures before since are forgotten.
+func (m *MemoryModel) ReserveLogin(ctx context.Context, key string, at, since time.Time, wait func(*LoginAttempt) time.Duration) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for k, a := range m.loginAttempts {
+		if a.LastFailure.Before(since) {
+			delete(m.loginAttempts, k)
+		}
+	}
+
+	a, ok := m.loginAttempts[key]
+	if !ok {
+		a = &LoginAttempt{Key: key, LastFailure: at}
+	}
+
+	c := *a
+	if d := wait(&c); d > 0 {
+		return d, nil
+	}
+
+	a.Failures++
+	a.LastFailure = at
+	m.loginAttempts[key] = a
+
+	return 0, nil
+}
+
+// ReleaseLogin takes back one attempt reserved under a key, for a login
+// that turned out not to fail
+func (m *MemoryModel) ReleaseLogin(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if a, ok := m.loginAttempts[key]; ok && a.Failures > 0 {
+		a.Failures--
+	}
+
+	return nil
+}
+
+// ClearLoginAttempts forgets the failed logins counted under the given keys
+func (m *MemoryModel) ClearLoginAttempts(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		delete(m.loginAttempts, key)
+	}
+
+	return nil
+}
+
+// UnlockUser forgets the failed logins to a user's email address, lifting
+// any lockout of the account
+func (m *MemoryModel) UnlockUser(ctx context.Context, id int, actor Actor) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	key := AccountLoginKey(user.Email)
+	failures := 0
+	if a, ok := m.loginAttempts[key]; ok {
+		failures = a.Failures
+		delete(m.loginAttempts, key)
+	}
+
+	return m.audit(actor, ActionUserUnlock, "user", id, map[string]int{"failed_logins": failures}, nil)
+}
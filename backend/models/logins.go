@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// ActionUserUnlock is the audit action of lifting a login lockout
+const ActionUserUnlock = "user.unlock"
+
+// LoginAttempt counts the failed logins of one client IP or email address
+type LoginAttempt struct {
+	Key         string
+	Failures    int
+	LastFailure time.Time
+}
+
+// IPLoginKey returns the key under which failed logins from an IP are counted
+func IPLoginKey(ip string) string {
+	return "ip:" + ip
+}
+
+// AccountLoginKey returns the key under which failed logins to an email
+// address are counted, whether or not it is registered
+func AccountLoginKey(email string) string {
+	return "account:" + NormalizeEmail(email)
+}
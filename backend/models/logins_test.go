@@ -0,0 +1,63 @@
+package models
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReserveLoginCountsConcurrentAttempts(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryModel()
+
+	const free = 3
+	now := time.Now()
+	since := now.Add(-time.Hour)
+
+	// attempts past the first free ones are refused for a minute
+	wait := func(a *LoginAttempt) time.Duration {
+		if a.Failures < free {
+			return 0
+		}
+		return time.Minute
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d, err := m.ReserveLogin(ctx, "account:a@example.com", now, since, wait)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if d == 0 {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != free {
+		t.Errorf("%d concurrent attempts went ahead, want %d", allowed, free)
+	}
+
+	if err := m.ReleaseLogin(ctx, "account:a@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	d, err := m.ReserveLogin(ctx, "account:a@example.com", now, since, wait)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d != 0 {
+		t.Errorf("attempt after a release waits %v, want it to go ahead", d)
+	}
+}
@@ -0,0 +1,270 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration is a single versioned schema change
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a migration and when it was applied, if it has been
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrations returns the embedded migrations ordered by version. Files are
+// named <version>_<name>.up.sql and <version>_<name>.down.sql.
+func Migrations() ([]Migration, error) {
+	files, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, file := range files {
+		base := strings.TrimSuffix(path.Base(file), ".sql")
+
+		var direction string
+		switch {
+		case strings.HasSuffix(base, ".up"):
+			direction = "up"
+		case strings.HasSuffix(base, ".down"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration %s: missing .up or .down suffix", file)
+		}
+		base = strings.TrimSuffix(base, "."+direction)
+
+		parts := strings.SplitN(base, "_", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("migration %s: expected <version>_<name>", file)
+		}
+		version, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version: %w", file, err)
+		}
+
+		body, err := migrationFiles.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: parts[1]}
+			byVersion[version] = migration
+		}
+		if migration.Name != parts[1] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, migration.Name, parts[1])
+		}
+
+		if direction == "up" {
+			migration.Up = string(body)
+		} else {
+			migration.Down = string(body)
+		}
+	}
+
+	var migrations []Migration
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Migrator applies the embedded migrations to a database and records them in
+// the schema_migrations table
+type Migrator struct {
+	DB *sql.DB
+}
+
+// Up applies every pending migration in order and returns the ones it applied
+func (m *Migrator) Up() ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		if err := m.run(migration, true); err != nil {
+			return done, fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Down rolls back the given number of most recently applied migrations and
+// returns the ones it rolled back
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == "" {
+			return done, fmt.Errorf("migration %d_%s has no down script", migration.Version, migration.Name)
+		}
+
+		if err := m.run(migration, false); err != nil {
+			return done, fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Status returns every embedded migration with the time it was applied, if any
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var status []MigrationStatus
+	for _, migration := range migrations {
+		s := MigrationStatus{Migration: migration}
+		if at, ok := applied[migration.Version]; ok {
+			at := at
+			s.AppliedAt = &at
+		}
+		status = append(status, s)
+	}
+
+	return status, nil
+}
+
+// applied creates the tracking table if needed and returns the applied
+// versions with their timestamps
+func (m *Migrator) applied() (map[int]time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stmt := `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version integer PRIMARY KEY,
+		name varchar(255) NOT NULL,
+		applied_at timestamp NOT NULL DEFAULT now()
+	)
+	`
+	if _, err := m.DB.ExecContext(ctx, stmt); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.DB.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+
+	return applied, rows.Err()
+}
+
+// run executes the up or down script of a migration and its bookkeeping
+// statement in one transaction. The tracking table is locked so concurrent
+// runs cannot apply the same migration twice.
+func (m *Migrator) run(migration Migration, up bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, "LOCK TABLE schema_migrations IN EXCLUSIVE MODE"); err != nil {
+		return err
+	}
+
+	var applied bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)", migration.Version).Scan(&applied)
+	if err != nil {
+		return err
+	}
+	if applied == up {
+		// another process got here first
+		return tx.Commit()
+	}
+
+	if up {
+		if _, err = tx.ExecContext(ctx, migration.Up); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+	} else {
+		if _, err = tx.ExecContext(ctx, migration.Down); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
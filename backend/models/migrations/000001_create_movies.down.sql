@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS movies_genres;
+DROP TABLE IF EXISTS movies;
+DROP TABLE IF EXISTS genres;
@@ -0,0 +1,33 @@
+-- IF NOT EXISTS lets databases created before migrations existed adopt this
+-- baseline without dropping anything.
+CREATE TABLE IF NOT EXISTS genres (
+	id serial PRIMARY KEY,
+	genre_name varchar(255) NOT NULL,
+	created_at timestamp NOT NULL DEFAULT now(),
+	updated_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS movies (
+	id serial PRIMARY KEY,
+	title varchar(255) NOT NULL,
+	description text NOT NULL DEFAULT '',
+	year integer NOT NULL,
+	release_date date NOT NULL,
+	runtime integer NOT NULL,
+	rating integer NOT NULL,
+	mpaa_rating varchar(10) NOT NULL DEFAULT '',
+	created_at timestamp NOT NULL DEFAULT now(),
+	updated_at timestamp NOT NULL DEFAULT now(),
+	poster varchar(255)
+);
+
+CREATE TABLE IF NOT EXISTS movies_genres (
+	id serial PRIMARY KEY,
+	movie_id integer NOT NULL REFERENCES movies (id) ON DELETE CASCADE,
+	genre_id integer NOT NULL REFERENCES genres (id),
+	created_at timestamp NOT NULL DEFAULT now(),
+	updated_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS movies_genres_movie_id_idx ON movies_genres (movie_id);
+CREATE INDEX IF NOT EXISTS movies_genres_genre_id_idx ON movies_genres (genre_id);
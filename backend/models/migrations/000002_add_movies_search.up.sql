@@ -0,0 +1,7 @@
+ALTER TABLE movies ADD COLUMN search_vector tsvector
+	GENERATED ALWAYS AS (
+		setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+		setweight(to_tsvector('english', coalesce(description, '')), 'B')
+	) STORED;
+
+CREATE INDEX movies_search_vector_idx ON movies USING gin (search_vector);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS genres_genre_name_key;
@@ -0,0 +1 @@
+CREATE UNIQUE INDEX genres_genre_name_key ON genres (lower(genre_name));
@@ -0,0 +1 @@
+ALTER TABLE movies ADD COLUMN version integer NOT NULL DEFAULT 1;
@@ -0,0 +1,16 @@
+CREATE TABLE audit_log (
+	id bigserial PRIMARY KEY,
+	actor_id integer NOT NULL,
+	action varchar(50) NOT NULL,
+	entity varchar(50) NOT NULL,
+	entity_id integer NOT NULL,
+	before jsonb,
+	after jsonb,
+	changes jsonb NOT NULL DEFAULT '{}',
+	request_id varchar(100) NOT NULL DEFAULT '',
+	created_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX audit_log_entity_idx ON audit_log (entity, entity_id, id);
+CREATE INDEX audit_log_actor_id_idx ON audit_log (actor_id, id);
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at);
@@ -0,0 +1,10 @@
+CREATE TABLE movie_revisions (
+	id bigserial PRIMARY KEY,
+	movie_id integer NOT NULL REFERENCES movies (id) ON DELETE CASCADE,
+	revision integer NOT NULL,
+	action varchar(50) NOT NULL,
+	actor_id integer NOT NULL,
+	snapshot jsonb NOT NULL,
+	created_at timestamp NOT NULL DEFAULT now(),
+	UNIQUE (movie_id, revision)
+);
@@ -0,0 +1,24 @@
+CREATE TABLE people (
+	id serial PRIMARY KEY,
+	name varchar(255) NOT NULL,
+	birth_date date,
+	biography text NOT NULL DEFAULT '',
+	created_at timestamp NOT NULL DEFAULT now(),
+	updated_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX people_name_idx ON people (lower(name));
+
+CREATE TABLE movie_credits (
+	id serial PRIMARY KEY,
+	movie_id integer NOT NULL REFERENCES movies (id) ON DELETE CASCADE,
+	person_id integer NOT NULL REFERENCES people (id),
+	role varchar(20) NOT NULL CHECK (role IN ('director', 'writer', 'producer', 'actor')),
+	character_name varchar(255) NOT NULL DEFAULT '',
+	billing_order integer NOT NULL DEFAULT 0,
+	created_at timestamp NOT NULL DEFAULT now(),
+	updated_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX movie_credits_movie_id_idx ON movie_credits (movie_id, billing_order);
+CREATE INDEX movie_credits_person_id_idx ON movie_credits (person_id);
@@ -0,0 +1,5 @@
+ALTER TABLE movies
+	DROP COLUMN IF EXISTS audience_total,
+	DROP COLUMN IF EXISTS audience_count;
+
+DROP TABLE IF EXISTS reviews;
@@ -0,0 +1,18 @@
+CREATE TABLE reviews (
+	id serial PRIMARY KEY,
+	movie_id integer NOT NULL REFERENCES movies (id) ON DELETE CASCADE,
+	user_id integer NOT NULL,
+	score integer NOT NULL CHECK (score BETWEEN 1 AND 5),
+	body text NOT NULL DEFAULT '',
+	created_at timestamp NOT NULL DEFAULT now(),
+	updated_at timestamp NOT NULL DEFAULT now(),
+	UNIQUE (movie_id, user_id)
+);
+
+CREATE INDEX reviews_movie_id_idx ON reviews (movie_id, id);
+
+-- running totals kept up to date by the review statements, so that movie
+-- queries never aggregate reviews
+ALTER TABLE movies
+	ADD COLUMN audience_count integer NOT NULL DEFAULT 0,
+	ADD COLUMN audience_total integer NOT NULL DEFAULT 0;
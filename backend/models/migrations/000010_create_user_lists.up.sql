@@ -0,0 +1,10 @@
+CREATE TABLE user_lists (
+	id serial PRIMARY KEY,
+	user_id integer NOT NULL,
+	list varchar(20) NOT NULL CHECK (list IN ('watchlist', 'favourites')),
+	movie_id integer NOT NULL REFERENCES movies (id) ON DELETE CASCADE,
+	created_at timestamp NOT NULL DEFAULT now(),
+	UNIQUE (user_id, list, movie_id)
+);
+
+CREATE INDEX user_lists_user_idx ON user_lists (user_id, list, id);
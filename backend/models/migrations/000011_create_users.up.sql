@@ -0,0 +1,10 @@
+CREATE TABLE users (
+	id serial PRIMARY KEY,
+	email varchar(255) NOT NULL,
+	password_hash text NOT NULL,
+	created_at timestamp NOT NULL DEFAULT now(),
+	updated_at timestamp NOT NULL DEFAULT now()
+);
+
+-- emails are stored lower case; the index keeps them unique regardless
+CREATE UNIQUE INDEX users_email_idx ON users (lower(email));
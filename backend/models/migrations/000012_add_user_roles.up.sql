@@ -0,0 +1,2 @@
+ALTER TABLE users
+	ADD COLUMN role varchar(10) NOT NULL DEFAULT 'viewer' CHECK (role IN ('viewer', 'editor', 'admin'));
@@ -0,0 +1,22 @@
+-- refresh tokens are stored as SHA-256 hashes. Every login starts a family
+-- and every refresh replaces the family's current token with a new one, so
+-- a used token presented again means it was stolen.
+CREATE TABLE refresh_tokens (
+	id serial PRIMARY KEY,
+	user_id integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	family varchar(32) NOT NULL,
+	token_hash bytea NOT NULL UNIQUE,
+	expires_at timestamp NOT NULL,
+	used_at timestamp,
+	revoked_at timestamp,
+	created_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX refresh_tokens_family_idx ON refresh_tokens (family);
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens (user_id);
+
+-- access tokens revoked before they expire, by JWT ID
+CREATE TABLE denied_tokens (
+	jti varchar(64) PRIMARY KEY,
+	expires_at timestamp NOT NULL
+);
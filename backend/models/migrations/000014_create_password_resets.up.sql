@@ -0,0 +1,11 @@
+-- reset tokens are stored as SHA-256 hashes and can be used once
+CREATE TABLE password_resets (
+	id serial PRIMARY KEY,
+	user_id integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	token_hash bytea NOT NULL UNIQUE,
+	expires_at timestamp NOT NULL,
+	used_at timestamp,
+	created_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX password_resets_user_id_idx ON password_resets (user_id);
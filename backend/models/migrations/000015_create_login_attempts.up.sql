@@ -0,0 +1,8 @@
+-- failed logins per client IP and per email address
+CREATE TABLE login_attempts (
+	key varchar(300) PRIMARY KEY,
+	failures integer NOT NULL,
+	last_failure timestamp NOT NULL
+);
+
+CREATE INDEX login_attempts_last_failure_idx ON login_attempts (last_failure);
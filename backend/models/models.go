@@ -1,36 +1,158 @@
 package models
 
 import (
+	"context"
 	"database/sql"
+	"sort"
+	"strings"
 	"time"
 )
 
+// MovieStore is the interface implemented by every movie storage backend
+type MovieStore interface {
+	Get(ctx context.Context, id int) (*Movie, error)
+	All(ctx context.Context, filter MovieFilter) ([]*Movie, string, error)
+	GenresAll(ctx context.Context) ([]*Genre, error)
+	InsertGenre(ctx context.Context, name string, actor Actor) (*Genre, error)
+	UpdateGenre(ctx context.Context, genre Genre, actor Actor) error
+	DeleteGenre(ctx context.Context, id int, actor Actor) error
+	InsertMovie(ctx context.Context, movie Movie, actor Actor) (int, error)
+	UpdateMovie(ctx context.Context, movie Movie, actor Actor) error
+	DeleteMovie(ctx context.Context, id int, actor Actor) error
+	Trash(ctx context.Context) ([]*Movie, error)
+	RestoreMovie(ctx context.Context, id int, actor Actor) error
+	PurgeTrash(ctx context.Context, before time.Time, actor Actor) (int, error)
+	AuditLog(ctx context.Context, filter AuditFilter) ([]*AuditEntry, string, error)
+	Revisions(ctx context.Context, movieID int) ([]*Revision, error)
+	Revision(ctx context.Context, movieID, revision int) (*Revision, error)
+	RevertMovie(ctx context.Context, movieID, revision, version int, actor Actor) error
+	People(ctx context.Context, name string, page Page) ([]*Person, string, error)
+	Person(ctx context.Context, id int) (*Person, error)
+	InsertPerson(ctx context.Context, person Person, actor Actor) (*Person, error)
+	UpdatePerson(ctx context.Context, person Person, actor Actor) error
+	DeletePerson(ctx context.Context, id int, actor Actor) error
+	InsertCredit(ctx context.Context, credit Credit, actor Actor) (*Credit, error)
+	UpdateCredit(ctx context.Context, credit Credit, actor Actor) error
+	DeleteCredit(ctx context.Context, id int, actor Actor) error
+	Reviews(ctx context.Context, movieID int, page Page) ([]*Review, string, error)
+	InsertReview(ctx context.Context, review Review) (*Review, error)
+	UpdateReview(ctx context.Context, review Review) (*Review, error)
+	DeleteReview(ctx context.Context, movieID, userID int) error
+	ListMovies(ctx context.Context, userID int, list string, page Page) ([]*Movie, string, error)
+	AddToList(ctx context.Context, userID int, list string, movieID int) error
+	RemoveFromList(ctx context.Context, userID int, list string, movieID int) error
+	InWatchlist(ctx context.Context, userID int, movieIDs []int) (map[int]bool, error)
+	UserByEmail(ctx context.Context, email string) (*User, error)
+	InsertUser(ctx context.Context, user User) (*User, error)
+	UpdateUserPassword(ctx context.Context, id int, hash string) error
+	UpdateUserRole(ctx context.Context, id int, role string, actor Actor) error
+	InsertRefreshToken(ctx context.Context, token RefreshToken) error
+	RotateRefreshToken(ctx context.Context, hash []byte, next RefreshToken) (*User, error)
+	RevokeRefreshToken(ctx context.Context, userID int, hash []byte) error
+	DenyToken(ctx context.Context, jti string, expires time.Time) error
+	TokenDenied(ctx context.Context, jti string) (bool, error)
+	InsertPasswordReset(ctx context.Context, reset PasswordReset) error
+	PasswordResetUser(ctx context.Context, hash []byte) (*User, error)
+	ResetPassword(ctx context.Context, hash []byte, passwordHash string) error
+	ReserveLogin(ctx context.Context, key string, at, since time.Time, wait func(*LoginAttempt) time.Duration) (time.Duration, error)
+	ReleaseLogin(ctx context.Context, key string) error
+	ClearLoginAttempts(ctx context.Context, keys ...string) error
+	UnlockUser(ctx context.Context, id int, actor Actor) error
+	ExportMovies(ctx context.Context, filter MovieFilter, fn func(*Movie) error) error
+	ImportMovies(ctx context.Context, rows []ImportRow, opts ImportOptions, actor Actor) (*ImportResult, error)
+	Search(ctx context.Context, query string, limit int) ([]*SearchResult, error)
+}
+
 // Models is the wrapper for database
 type Models struct {
-	DB DBModel
+	DB MovieStore
+}
+
+// NewModels returns models with db pool, bounding every query by timeout
+func NewModels(db *sql.DB, timeout time.Duration) Models {
+	return Models{
+		DB: &DBModel{DB: db, Timeout: timeout},
+	}
 }
 
-// NewModels returns models with db pool
-func NewModels(db *sql.DB) Models {
+// NewMemoryModels returns models backed by an in-memory store
+func NewMemoryModels() Models {
 	return Models{
-		DB: DBModel{DB: db},
+		DB: NewMemoryModel(),
 	}
 }
 
 // Movie is the type for a movie
 type Movie struct {
-	ID          int            `json:"id"`
-	Title       string         `json:"title"`
-	Description string         `json:"description"`
-	Year        int            `json:"year"`
-	ReleaseDate time.Time      `json:"release_date"`
-	Runtime     int            `json:"runtime"`
-	Rating      int            `json:"rating"`
-	MPAARating  string         `json:"mpaa_rating"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	MovieGenre  map[int]string `json:"genres"`
-	Poster      string         `json:"poster"`
+	ID             int            `json:"id"`
+	Title          string         `json:"title"`
+	Description    string         `json:"description"`
+	Year           int            `json:"year"`
+	ReleaseDate    time.Time      `json:"release_date"`
+	Runtime        int            `json:"runtime"`
+	Rating         int            `json:"rating"`
+	AudienceRating float64        `json:"audience_rating"` // average review score
+	AudienceCount  int            `json:"audience_count"`  // number of reviews
+	MPAARating     string         `json:"mpaa_rating"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	MovieGenre     map[int]string `json:"genres"` // genre names keyed by genre ID
+	Poster         string         `json:"poster"`
+	DeletedAt      *time.Time     `json:"deleted_at,omitempty"`
+	Version        int            `json:"version"`
+	Credits        []*Credit      `json:"credits,omitempty"`      // only filled in by Get
+	InWatchlist    *bool          `json:"in_watchlist,omitempty"` // set for authenticated requests only
+}
+
+// GenreIDs returns the IDs of the movie's genres in ascending order
+func (m *Movie) GenreIDs() []int {
+	ids := make([]int, 0, len(m.MovieGenre))
+	for id := range m.MovieGenre {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// Movie field limits
+const (
+	maxTitleLength      = 255
+	maxMPAARatingLength = 10
+	maxRating           = 5
+)
+
+// Validate checks the fields a movie is saved with and returns an
+// ErrValidation error for the first one that is out of range
+func (m *Movie) Validate() error {
+	if strings.TrimSpace(m.Title) == "" {
+		return validationErrorf("title is required")
+	}
+	if len(m.Title) > maxTitleLength {
+		return validationErrorf("title must be at most %d characters", maxTitleLength)
+	}
+	if m.Runtime < 0 {
+		return validationErrorf("runtime must be a positive number")
+	}
+	if m.Rating < 0 || m.Rating > maxRating {
+		return validationErrorf("rating must be a number from 0 to %d", maxRating)
+	}
+	if len(m.MPAARating) > maxMPAARatingLength {
+		return validationErrorf("mpaa_rating must be at most %d characters", maxMPAARatingLength)
+	}
+	for id := range m.MovieGenre {
+		if id < 1 {
+			return validationErrorf("genre_ids must be a list of genre IDs")
+		}
+	}
+	return nil
+}
+
+// SearchResult is a movie matched by a full-text search, with its relevance
+// and a highlighted snippet of its description
+type SearchResult struct {
+	Movie
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
 }
 
 // Genre is the type for a genre
@@ -50,10 +172,3 @@ type MovieGenre struct {
 	CreatedAt time.Time `json:"-"`
 	UpdatedAt time.Time `json:"-"`
 }
-
-// User is the type for users
-type User struct {
-	ID       int
-	Email    string
-	Password string
-}
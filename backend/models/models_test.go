@@ -0,0 +1,42 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMovieValidate(t *testing.T) {
+	valid := Movie{Title: "Heat", Runtime: 170, Rating: 5, MPAARating: "R", MovieGenre: map[int]string{1: ""}}
+
+	tests := []struct {
+		name  string
+		edit  func(m *Movie)
+		valid bool
+	}{
+		{"valid", func(m *Movie) {}, true},
+		{"no runtime or rating", func(m *Movie) { m.Runtime, m.Rating = 0, 0 }, true},
+		{"blank title", func(m *Movie) { m.Title = "  " }, false},
+		{"long title", func(m *Movie) { m.Title = strings.Repeat("a", 256) }, false},
+		{"negative runtime", func(m *Movie) { m.Runtime = -1 }, false},
+		{"negative rating", func(m *Movie) { m.Rating = -1 }, false},
+		{"rating above 5", func(m *Movie) { m.Rating = 6 }, false},
+		{"long mpaa rating", func(m *Movie) { m.MPAARating = "NOT RATED YET" }, false},
+		{"invalid genre ID", func(m *Movie) { m.MovieGenre = map[int]string{0: ""} }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			movie := valid
+			tt.edit(&movie)
+
+			err := movie.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("got %v, want no error", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrValidation) {
+				t.Errorf("got %v, want ErrValidation", err)
+			}
+		})
+	}
+}
@@ -4,29 +4,80 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
+
+	"github.com/lib/pq"
 )
 
+// DefaultTimeout is the per-query timeout used when DBModel.Timeout is zero
+const DefaultTimeout = 3 * time.Second
+
 // DBModel is the type for a DB model
 type DBModel struct {
-	DB *sql.DB
+	DB      *sql.DB
+	Timeout time.Duration
+}
+
+var _ MovieStore = (*DBModel)(nil)
+
+// withTimeout bounds a query by the model's timeout. The query is also
+// cancelled as soon as ctx is, e.g. when the client disconnects.
+func (m *DBModel) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, m.timeout())
+}
+
+// timeout returns the per-query timeout
+func (m *DBModel) timeout() time.Duration {
+	if m.Timeout <= 0 {
+		return DefaultTimeout
+	}
+	return m.Timeout
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
 }
 
-// Get returns one movie and an error, if any
-func (m *DBModel) Get(id int) (*Movie, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+// Get returns one movie with its credits and an error, if any
+func (m *DBModel) Get(ctx context.Context, id int) (*Movie, error) {
+	ctx, cancel := m.withTimeout(ctx)
 	defer cancel()
 
+	movie, err := getMovie(ctx, m.DB, id, false)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	if movie.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+
+	if movie.Credits, err = creditsFor(ctx, m.DB, id); err != nil {
+		return nil, dbError(err)
+	}
+
+	return movie, nil
+}
+
+// getMovie returns one movie with its genres, whether or not it is in the
+// trash. With lock set the row is locked for the rest of the transaction.
+func getMovie(ctx context.Context, q queryer, id int, lock bool) (*Movie, error) {
 	query := `
 	SELECT
-		id, title, description, year, release_date, rating, runtime, mpaa_rating, created_at, updated_at, coalesce(poster, '')
+		id, title, description, year, release_date, rating, runtime, mpaa_rating, created_at, updated_at, coalesce(poster, ''), version, audience_count, coalesce(round(audience_total::numeric / nullif(audience_count, 0), 2), 0), deleted_at
 	FROM
 		movies
 	WHERE
 		id = $1
 	`
+	if lock {
+		query += " FOR UPDATE"
+	}
 
-	row := m.DB.QueryRowContext(ctx, query, id)
+	row := q.QueryRowContext(ctx, query, id)
 
 	var movie Movie
 	err := row.Scan(
@@ -41,68 +92,54 @@ func (m *DBModel) Get(id int) (*Movie, error) {
 		&movie.CreatedAt,
 		&movie.UpdatedAt,
 		&movie.Poster,
+		&movie.Version,
+		&movie.AudienceCount,
+		&movie.AudienceRating,
+		&movie.DeletedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	// get the genres
-	query = `
-	SELECT
-		mg.id, mg.movie_id, mg.genre_id, g.genre_name
-	FROM
-		movies_genres mg
-		left join genres g on (g.id = mg.genre_id)
-	WHERE
-		mg.movie_id = $1
-	`
-	rows, err := m.DB.QueryContext(ctx, query, id)
+	genres, err := genresFor(ctx, q, []int{movie.ID})
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	genres := make(map[int]string)
-	for rows.Next() {
-		var mg MovieGenre
-		err := rows.Scan(
-			&mg.ID,
-			&mg.MovieID,
-			&mg.GenreID,
-			&mg.Genre.GenreName,
-		)
-		if err != nil {
-			return nil, err
-		}
-		genres[mg.ID] = mg.Genre.GenreName
-	}
-
-	movie.MovieGenre = genres
+	movie.MovieGenre = genres[movie.ID]
 
 	return &movie, nil
 }
 
-// All returns all movies and an error, if any
-func (m *DBModel) All(genre ...int) ([]*Movie, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+// All returns one page of movies matching the filter, the cursor of the
+// next page (empty on the last page) and an error, if any
+func (m *DBModel) All(ctx context.Context, filter MovieFilter) ([]*Movie, string, error) {
+	ctx, cancel := m.withTimeout(ctx)
 	defer cancel()
 
-	where := ""
-	if len(genre) > 0 {
-		where = fmt.Sprintf("WHERE id IN (SELECT movie_id FROM movies_genres WHERE genre_id = %d)", genre[0])
+	where, orderBy, args, err := movieFilterSQL(filter)
+	if err != nil {
+		return nil, "", dbError(err)
+	}
+
+	limit := ""
+	if filter.Limit > 0 {
+		// fetch one extra row to find out whether there is a next page
+		args = append(args, filter.Limit+1)
+		limit = fmt.Sprintf("LIMIT $%d", len(args))
 	}
 
 	query := fmt.Sprintf(`
 	SELECT
-		id, title, description, year, release_date, runtime, rating, mpaa_rating, created_at, updated_at, coalesce(poster, '')
+		id, title, description, year, release_date, runtime, rating, mpaa_rating, created_at, updated_at, coalesce(poster, ''), version, audience_count, coalesce(round(audience_total::numeric / nullif(audience_count, 0), 2), 0)
 	FROM
 		movies %s
 	ORDER BY
-		title
-	`, where)
-	rows, err := m.DB.QueryContext(ctx, query)
+		%s
+	%s
+	`, where, orderBy, limit)
+	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, "", dbError(err)
 	}
 	defer rows.Close()
 
@@ -122,51 +159,234 @@ func (m *DBModel) All(genre ...int) ([]*Movie, error) {
 			&movie.CreatedAt,
 			&movie.UpdatedAt,
 			&movie.Poster,
+			&movie.Version,
+			&movie.AudienceCount,
+			&movie.AudienceRating,
 		)
 		if err != nil {
-			return nil, err
+			return nil, "", dbError(err)
 		}
 
-		// get the genres
-		genreQuery := `
-		SELECT
-			mg.id, mg.movie_id, mg.genre_id, g.genre_name
-		FROM
-			movies_genres mg
-			left join genres g on (g.id = mg.genre_id)
-		WHERE
-			mg.movie_id = $1
-		`
-		genreRows, err := m.DB.QueryContext(ctx, genreQuery, movie.ID)
+		movies = append(movies, &movie)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", dbError(err)
+	}
+	rows.Close()
+
+	next := ""
+	if filter.Limit > 0 && len(movies) > filter.Limit {
+		movies = movies[:filter.Limit]
+		key, _ := filter.sortKey()
+		next = encodeCursor(movies[len(movies)-1], key)
+	}
+
+	// get the genres for every movie in one round trip
+	ids := make([]int, len(movies))
+	for i, movie := range movies {
+		ids[i] = movie.ID
+	}
+
+	genres, err := genresFor(ctx, m.DB, ids)
+	if err != nil {
+		return nil, "", dbError(err)
+	}
+
+	for _, movie := range movies {
+		movie.MovieGenre = genres[movie.ID]
+	}
+
+	return movies, next, nil
+}
+
+// movieFilterSQL builds the WHERE and ORDER BY clauses for a filter. Every
+// value is passed as a query argument and the sort column comes from a
+// whitelist, so nothing user supplied is formatted into the SQL.
+func movieFilterSQL(filter MovieFilter) (string, string, []interface{}, error) {
+	if err := filter.Validate(); err != nil {
+		return "", "", nil, err
+	}
+
+	where := []string{"deleted_at IS NULL"}
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.YearFrom > 0 {
+		where = append(where, "year >= "+arg(filter.YearFrom))
+	}
+	if filter.YearTo > 0 {
+		where = append(where, "year <= "+arg(filter.YearTo))
+	}
+	if filter.MinRating > 0 {
+		where = append(where, "rating >= "+arg(filter.MinRating))
+	}
+	if len(filter.MPAARating) > 0 {
+		where = append(where, "mpaa_rating = ANY("+arg(pq.StringArray(filter.MPAARating))+")")
+	}
+	if filter.RuntimeMin > 0 {
+		where = append(where, "runtime >= "+arg(filter.RuntimeMin))
+	}
+	if filter.RuntimeMax > 0 {
+		where = append(where, "runtime <= "+arg(filter.RuntimeMax))
+	}
+	if len(filter.Genres) > 0 {
+		genres := make([]int64, len(filter.Genres))
+		for i, g := range filter.Genres {
+			genres[i] = int64(g)
+		}
+		where = append(where, "id IN (SELECT movie_id FROM movies_genres WHERE genre_id = ANY("+arg(pq.Int64Array(genres))+"))")
+	}
+
+	key, desc := filter.sortKey()
+	column := movieSortColumns[key]
+	direction, comparison := "ASC", ">"
+	if desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if filter.Cursor != "" {
+		after, err := decodeCursor(filter.Cursor, key)
+		if err != nil {
+			return "", "", nil, err
+		}
+		where = append(where, fmt.Sprintf("(%s, id) %s (%s, %s)", column, comparison, arg(sortValue(after, key)), arg(after.ID)))
+	}
+
+	return "WHERE " + strings.Join(where, " AND "), fmt.Sprintf("%s %s, id %s", column, direction, direction), args, nil
+}
+
+// genresFor returns the genres of the given movies keyed by movie ID, loaded
+// with a single query
+func genresFor(ctx context.Context, q queryer, ids []int) (map[int]map[int]string, error) {
+	movieIDs := make([]int64, len(ids))
+	for i, id := range ids {
+		movieIDs[i] = int64(id)
+	}
+
+	query := `
+	SELECT
+		mg.id, mg.movie_id, mg.genre_id, g.genre_name
+	FROM
+		movies_genres mg
+		left join genres g on (g.id = mg.genre_id)
+	WHERE
+		mg.movie_id = ANY($1)
+	`
+	rows, err := q.QueryContext(ctx, query, pq.Int64Array(movieIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	genres := make(map[int]map[int]string, len(ids))
+	for _, id := range ids {
+		genres[id] = make(map[int]string)
+	}
+
+	for rows.Next() {
+		var mg MovieGenre
+		err := rows.Scan(
+			&mg.ID,
+			&mg.MovieID,
+			&mg.GenreID,
+			&mg.Genre.GenreName,
+		)
 		if err != nil {
 			return nil, err
 		}
+		genres[mg.MovieID][mg.GenreID] = mg.Genre.GenreName
+	}
 
-		genres := make(map[int]string)
-		for genreRows.Next() {
-			var mg MovieGenre
-			err := genreRows.Scan(
-				&mg.ID,
-				&mg.MovieID,
-				&mg.GenreID,
-				&mg.Genre.GenreName,
-			)
-			if err != nil {
-				return nil, err
-			}
-			genres[mg.ID] = mg.Genre.GenreName
+	return genres, rows.Err()
+}
+
+// Search returns the movies whose title or description match every word of
+// the query as a prefix, best matches first. Title matches rank above
+// description matches.
+func (m *DBModel) Search(ctx context.Context, q string, limit int) ([]*SearchResult, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	terms := searchTerms(q)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	query := `
+	SELECT
+		m.id, m.title, m.description, m.year, m.release_date, m.runtime, m.rating, m.mpaa_rating, m.created_at, m.updated_at, coalesce(m.poster, ''), m.version, m.audience_count, coalesce(round(m.audience_total::numeric / nullif(m.audience_count, 0), 2), 0),
+		ts_rank(m.search_vector, q) AS rank,
+		ts_headline('english', m.description, q, $2) AS snippet
+	FROM
+		movies m, to_tsquery('english', $1) q
+	WHERE
+		m.search_vector @@ q AND m.deleted_at IS NULL
+	ORDER BY
+		rank DESC, m.title, m.id
+	LIMIT $3
+	`
+	options := fmt.Sprintf("StartSel=%s, StopSel=%s, MaxWords=%d, MinWords=5, MaxFragments=2", highlightStart, highlightStop, snippetWords)
+
+	rows, err := m.DB.QueryContext(ctx, query, prefixQuery(terms), options, limit)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	var results []*SearchResult
+	for rows.Next() {
+		var result SearchResult
+		err := rows.Scan(
+			&result.ID,
+			&result.Title,
+			&result.Description,
+			&result.Year,
+			&result.ReleaseDate,
+			&result.Runtime,
+			&result.Rating,
+			&result.MPAARating,
+			&result.CreatedAt,
+			&result.UpdatedAt,
+			&result.Poster,
+			&result.Version,
+			&result.AudienceCount,
+			&result.AudienceRating,
+			&result.Rank,
+			&result.Snippet,
+		)
+		if err != nil {
+			return nil, dbError(err)
 		}
-		genreRows.Close()
 
-		movie.MovieGenre = genres
-		movies = append(movies, &movie)
+		results = append(results, &result)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, dbError(err)
 	}
+	rows.Close()
 
-	return movies, nil
+	ids := make([]int, len(results))
+	for i, result := range results {
+		ids[i] = result.ID
+	}
+
+	genres, err := genresFor(ctx, m.DB, ids)
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	for _, result := range results {
+		result.MovieGenre = genres[result.ID]
+	}
+
+	return results, nil
 }
 
-func (m *DBModel) GenresAll() ([]*Genre, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+func (m *DBModel) GenresAll(ctx context.Context) ([]*Genre, error) {
+	ctx, cancel := m.withTimeout(ctx)
 	defer cancel()
 
 	query := `
@@ -180,7 +400,7 @@ func (m *DBModel) GenresAll() ([]*Genre, error) {
 
 	rows, err := m.DB.QueryContext(ctx, query)
 	if err != nil {
-		return nil, err
+		return nil, dbError(err)
 	}
 	defer rows.Close()
 
@@ -194,7 +414,7 @@ func (m *DBModel) GenresAll() ([]*Genre, error) {
 			&genre.UpdatedAt,
 		)
 		if err != nil {
-			return nil, err
+			return nil, dbError(err)
 		}
 
 		genres = append(genres, &genre)
@@ -203,52 +423,237 @@ func (m *DBModel) GenresAll() ([]*Genre, error) {
 	return genres, nil
 }
 
-// InsertMovie inserts a movie into the database
-func (m *DBModel) InsertMovie(movie Movie) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+// InsertGenre inserts a genre and returns it with its new ID
+func (m *DBModel) InsertGenre(ctx context.Context, name string, actor Actor) (*Genre, error) {
+	ctx, cancel := m.withTimeout(ctx)
 	defer cancel()
 
-	stmt := `
-	INSERT INTO
-		movies (title, description, year, release_date, runtime, rating, mpaa_rating, created_at, updated_at, poster)
-	VALUES
-		($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	var genre Genre
+	err := m.withTx(ctx, func(tx *sql.Tx) error {
+		stmt := `
+		INSERT INTO
+			genres (genre_name, created_at, updated_at)
+		VALUES
+			($1, $2, $2)
+		RETURNING
+			id, genre_name, created_at, updated_at
+		`
+
+		err := tx.QueryRowContext(ctx, stmt, name, time.Now()).Scan(
+			&genre.ID,
+			&genre.GenreName,
+			&genre.CreatedAt,
+			&genre.UpdatedAt,
+		)
+		if err != nil {
+			if isPQError(err, pqUniqueViolation) {
+				return ErrDuplicateGenre
+			}
+			return err
+		}
+
+		return insertAudit(ctx, tx, actor, ActionGenreCreate, "genre", genre.ID, nil, genre)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &genre, nil
+}
+
+// UpdateGenre renames a genre
+func (m *DBModel) UpdateGenre(ctx context.Context, genre Genre, actor Actor) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		before, err := getGenre(ctx, tx, genre.ID)
+		if err != nil {
+			return err
+		}
+
+		stmt := `
+		UPDATE
+			genres
+		SET
+			genre_name = $1, updated_at = $2
+		WHERE
+			id = $3
+		`
+
+		_, err = tx.ExecContext(ctx, stmt, genre.GenreName, time.Now(), genre.ID)
+		if err != nil {
+			if isPQError(err, pqUniqueViolation) {
+				return ErrDuplicateGenre
+			}
+			return err
+		}
+
+		return insertAudit(ctx, tx, actor, ActionGenreRename, "genre", genre.ID, before, genre)
+	})
+}
+
+// DeleteGenre deletes a genre. Genres still assigned to a movie are not
+// deleted and ErrGenreInUse is returned instead.
+func (m *DBModel) DeleteGenre(ctx context.Context, id int, actor Actor) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		before, err := getGenre(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		var inUse bool
+		err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM movies_genres WHERE genre_id = $1)", id).Scan(&inUse)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return ErrGenreInUse
+		}
+
+		if _, err = tx.ExecContext(ctx, "DELETE FROM genres WHERE id = $1", id); err != nil {
+			if isPQError(err, pqForeignKeyViolation) {
+				return ErrGenreInUse
+			}
+			return err
+		}
+
+		return insertAudit(ctx, tx, actor, ActionGenreDelete, "genre", id, before, nil)
+	})
+}
+
+// getGenre returns one genre and locks it for the rest of the transaction
+func getGenre(ctx context.Context, tx *sql.Tx, id int) (*Genre, error) {
+	query := `
+	SELECT
+		id, genre_name, created_at, updated_at
+	FROM
+		genres
+	WHERE
+		id = $1
+	FOR UPDATE
 	`
 
-	_, err := m.DB.ExecContext(ctx, stmt,
-		movie.Title,
-		movie.Description,
-		movie.Year,
-		movie.ReleaseDate,
-		movie.Runtime,
-		movie.Rating,
-		movie.MPAARating,
-		movie.CreatedAt,
-		movie.UpdatedAt,
-		movie.Poster,
+	var genre Genre
+	err := tx.QueryRowContext(ctx, query, id).Scan(
+		&genre.ID,
+		&genre.GenreName,
+		&genre.CreatedAt,
+		&genre.UpdatedAt,
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return &genre, nil
+}
+
+// InsertMovie inserts a movie into the database and links it to the genres
+// keyed in movie.MovieGenre, in one transaction. It returns the new movie ID.
+func (m *DBModel) InsertMovie(ctx context.Context, movie Movie, actor Actor) (int, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	err := m.withTx(ctx, func(tx *sql.Tx) error {
+		stmt := `
+		INSERT INTO
+			movies (title, description, year, release_date, runtime, rating, mpaa_rating, created_at, updated_at, poster)
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING
+			id
+		`
+
+		err := tx.QueryRowContext(ctx, stmt,
+			movie.Title,
+			movie.Description,
+			movie.Year,
+			movie.ReleaseDate,
+			movie.Runtime,
+			movie.Rating,
+			movie.MPAARating,
+			movie.CreatedAt,
+			movie.UpdatedAt,
+			movie.Poster,
+		).Scan(&movie.ID)
+		if err != nil {
+			return err
+		}
+
+		if err = replaceGenres(ctx, tx, movie.ID, movie.GenreIDs()); err != nil {
+			return err
+		}
+
+		after, err := getMovie(ctx, tx, movie.ID, false)
+		if err != nil {
+			return err
+		}
+
+		return insertAudit(ctx, tx, actor, ActionMovieCreate, "movie", movie.ID, nil, after)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return movie.ID, nil
 }
 
-// UpdateMovie updates a movie in the database
-func (m *DBModel) UpdateMovie(movie Movie) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+// UpdateMovie updates a movie in the database and replaces its genre links
+// with the genres keyed in movie.MovieGenre, in one transaction. The update
+// only succeeds if movie.Version is still the stored version; otherwise
+// ErrEditConflict is returned. The previous state is kept as a revision and
+// the stored version is incremented.
+func (m *DBModel) UpdateMovie(ctx context.Context, movie Movie, actor Actor) error {
+	ctx, cancel := m.withTimeout(ctx)
 	defer cancel()
 
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		before, err := getMovie(ctx, tx, movie.ID, true)
+		if err != nil {
+			return err
+		}
+		if before.DeletedAt != nil {
+			return ErrNotFound
+		}
+		if before.Version != movie.Version {
+			return ErrEditConflict
+		}
+
+		if err = insertRevision(ctx, tx, before, ActionMovieUpdate, actor); err != nil {
+			return err
+		}
+
+		movie.DeletedAt = nil
+		if err = updateMovie(ctx, tx, movie); err != nil {
+			return err
+		}
+
+		after, err := getMovie(ctx, tx, movie.ID, false)
+		if err != nil {
+			return err
+		}
+
+		return insertAudit(ctx, tx, actor, ActionMovieUpdate, "movie", movie.ID, before, after)
+	})
+}
+
+// updateMovie writes every column of a movie, including whether it is in the
+// trash, bumps its version and replaces its genre links
+func updateMovie(ctx context.Context, tx *sql.Tx, movie Movie) error {
 	stmt := `
 	UPDATE
 		movies
 	SET
-		title = $1, description = $2, year = $3, release_date = $4, runtime = $5, rating = $6, mpaa_rating = $7, updated_at = $8, poster = $9
+		title = $1, description = $2, year = $3, release_date = $4, runtime = $5, rating = $6, mpaa_rating = $7, updated_at = $8, poster = $9,
+		deleted_at = $10, version = version + 1
 	WHERE
-		id = $10
+		id = $11
 	`
 
-	_, err := m.DB.ExecContext(ctx, stmt,
+	_, err := tx.ExecContext(ctx, stmt,
 		movie.Title,
 		movie.Description,
 		movie.Year,
@@ -258,25 +663,246 @@ func (m *DBModel) UpdateMovie(movie Movie) error {
 		movie.MPAARating,
 		movie.UpdatedAt,
 		movie.Poster,
+		movie.DeletedAt,
 		movie.ID,
 	)
 	if err != nil {
 		return err
 	}
 
+	return replaceGenres(ctx, tx, movie.ID, movie.GenreIDs())
+}
+
+// withTx runs fn in a transaction, committing if it returns nil and rolling
+// back otherwise. Errors are translated into the error kinds.
+func (m *DBModel) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return dbError(err)
+	}
+	defer tx.Rollback()
+
+	if err = fn(tx); err != nil {
+		return dbError(err)
+	}
+
+	return dbError(tx.Commit())
+}
+
+// replaceGenres replaces the genre links of a movie. It returns
+// ErrInvalidGenre if any of the genres does not exist.
+func replaceGenres(ctx context.Context, tx *sql.Tx, movieID int, genreIDs []int) error {
+	ids := make([]int64, len(genreIDs))
+	for i, id := range genreIDs {
+		ids[i] = int64(id)
+	}
+
+	if len(ids) > 0 {
+		var found int
+		err := tx.QueryRowContext(ctx, "SELECT count(*) FROM (SELECT id FROM genres WHERE id = ANY($1) FOR SHARE) g", pq.Int64Array(ids)).Scan(&found)
+		if err != nil {
+			return err
+		}
+		if found != len(ids) {
+			return ErrInvalidGenre
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM movies_genres WHERE movie_id = $1", movieID); err != nil {
+		return err
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	stmt := `
+	INSERT INTO
+		movies_genres (movie_id, genre_id, created_at, updated_at)
+	SELECT
+		$1, genre_id, now(), now()
+	FROM
+		unnest($2::integer[]) AS genre_id
+	`
+	if _, err := tx.ExecContext(ctx, stmt, movieID, pq.Int64Array(ids)); err != nil {
+		if isPQError(err, pqForeignKeyViolation) {
+			return ErrInvalidGenre
+		}
+		return err
+	}
+
 	return nil
 }
 
-// DeleteMovie deletes a movie from the database
-func (m *DBModel) DeleteMovie(id int) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+// DeleteMovie moves a movie to the trash, keeping its last state as a
+// revision. Trashed movies are hidden from every other query until they are
+// restored or purged.
+func (m *DBModel) DeleteMovie(ctx context.Context, id int, actor Actor) error {
+	return m.setDeleted(ctx, id, true, actor)
+}
+
+// Trash returns the movies in the trash, most recently deleted first
+func (m *DBModel) Trash(ctx context.Context) ([]*Movie, error) {
+	ctx, cancel := m.withTimeout(ctx)
 	defer cancel()
 
-	stmt := "DELETE FROM movies WHERE id = $1"
-	_, err := m.DB.ExecContext(ctx, stmt, id)
+	query := `
+	SELECT
+		id, title, description, year, release_date, runtime, rating, mpaa_rating, created_at, updated_at, coalesce(poster, ''), version, audience_count, coalesce(round(audience_total::numeric / nullif(audience_count, 0), 2), 0), deleted_at
+	FROM
+		movies
+	WHERE
+		deleted_at IS NOT NULL
+	ORDER BY
+		deleted_at DESC, id DESC
+	`
+	rows, err := m.DB.QueryContext(ctx, query)
 	if err != nil {
-		return err
+		return nil, dbError(err)
 	}
+	defer rows.Close()
 
-	return nil
+	var movies []*Movie
+	for rows.Next() {
+		var movie Movie
+		err := rows.Scan(
+			&movie.ID,
+			&movie.Title,
+			&movie.Description,
+			&movie.Year,
+			&movie.ReleaseDate,
+			&movie.Runtime,
+			&movie.Rating,
+			&movie.MPAARating,
+			&movie.CreatedAt,
+			&movie.UpdatedAt,
+			&movie.Poster,
+			&movie.Version,
+			&movie.AudienceCount,
+			&movie.AudienceRating,
+			&movie.DeletedAt,
+		)
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		movies = append(movies, &movie)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+	rows.Close()
+
+	ids := make([]int, len(movies))
+	for i, movie := range movies {
+		ids[i] = movie.ID
+	}
+
+	genres, err := genresFor(ctx, m.DB, ids)
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	for _, movie := range movies {
+		movie.MovieGenre = genres[movie.ID]
+	}
+
+	return movies, nil
+}
+
+// RestoreMovie takes a movie back out of the trash
+func (m *DBModel) RestoreMovie(ctx context.Context, id int, actor Actor) error {
+	return m.setDeleted(ctx, id, false, actor)
+}
+
+// setDeleted moves a movie into or out of the trash and records the change
+func (m *DBModel) setDeleted(ctx context.Context, id int, deleted bool, actor Actor) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		before, err := getMovie(ctx, tx, id, true)
+		if err != nil {
+			return err
+		}
+		if (before.DeletedAt != nil) == deleted {
+			return ErrNotFound
+		}
+
+		now := time.Now()
+		var deletedAt *time.Time
+		action := ActionMovieRestore
+		if deleted {
+			deletedAt = &now
+			action = ActionMovieDelete
+
+			if err = insertRevision(ctx, tx, before, action, actor); err != nil {
+				return err
+			}
+		}
+
+		// the version changes too, so that an edit based on the movie
+		// before it was deleted or restored is refused
+		stmt := "UPDATE movies SET deleted_at = $1, updated_at = $2, version = version + 1 WHERE id = $3"
+		if _, err = tx.ExecContext(ctx, stmt, deletedAt, now, id); err != nil {
+			return err
+		}
+
+		after, err := getMovie(ctx, tx, id, false)
+		if err != nil {
+			return err
+		}
+
+		return insertAudit(ctx, tx, actor, action, "movie", id, before, after)
+	})
+}
+
+// PurgeTrash permanently deletes the movies that were moved to the trash
+// before the given time and returns how many were deleted
+func (m *DBModel) PurgeTrash(ctx context.Context, before time.Time, actor Actor) (int, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	purged := 0
+	err := m.withTx(ctx, func(tx *sql.Tx) error {
+		stmt := `
+		DELETE FROM
+			movies
+		WHERE
+			deleted_at < $1
+		RETURNING
+			id, title, year, deleted_at
+		`
+
+		rows, err := tx.QueryContext(ctx, stmt, before)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		var movies []Movie
+		for rows.Next() {
+			var movie Movie
+			if err := rows.Scan(&movie.ID, &movie.Title, &movie.Year, &movie.DeletedAt); err != nil {
+				return err
+			}
+			movies = append(movies, movie)
+		}
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		rows.Close()
+
+		for _, movie := range movies {
+			snapshot := map[string]interface{}{"id": movie.ID, "title": movie.Title, "year": movie.Year, "deleted_at": movie.DeletedAt}
+			if err = insertAudit(ctx, tx, actor, ActionMoviePurge, "movie", movie.ID, snapshot, nil); err != nil {
+				return err
+			}
+		}
+
+		purged = len(movies)
+		return nil
+	})
+
+	return purged, err
 }
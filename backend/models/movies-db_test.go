@@ -0,0 +1,147 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingConnector opens connections to a fake database with the given
+// number of movies, each in one genre, and counts the queries run against it
+type countingConnector struct {
+	movies  int
+	queries int64
+}
+
+func (c *countingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &countingConn{c: c}, nil
+}
+
+func (c *countingConnector) Driver() driver.Driver {
+	return countingDriver{}
+}
+
+type countingDriver struct{}
+
+func (countingDriver) Open(name string) (driver.Conn, error) {
+	return nil, errors.New("use countingConnector")
+}
+
+type countingConn struct {
+	c *countingConnector
+}
+
+func (cn *countingConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare is not supported")
+}
+
+func (cn *countingConn) Close() error {
+	return nil
+}
+
+func (cn *countingConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+// QueryContext answers the movie list and genre queries of DBModel.All
+func (cn *countingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	atomic.AddInt64(&cn.c.queries, 1)
+
+	if strings.Contains(query, "movies_genres") {
+		return &countingRows{
+			columns: []string{"id", "movie_id", "genre_id", "genre_name"},
+			n:       cn.c.movies,
+			row: func(i int) []driver.Value {
+				return []driver.Value{int64(i), int64(i), int64(1), "Drama"}
+			},
+		}, nil
+	}
+
+	now := time.Now()
+	return &countingRows{
+		columns: []string{"id", "title", "description", "year", "release_date", "runtime", "rating", "mpaa_rating",
+			"created_at", "updated_at", "poster", "version", "audience_count", "audience_rating"},
+		n: cn.c.movies,
+		row: func(i int) []driver.Value {
+			return []driver.Value{int64(i), fmt.Sprintf("Movie %d", i), "", int64(2000), now, int64(90), int64(3), "PG",
+				now, now, "", int64(1), int64(0), float64(0)}
+		},
+	}, nil
+}
+
+type countingRows struct {
+	columns []string
+	n       int
+	next    int
+	row     func(i int) []driver.Value
+}
+
+func (r *countingRows) Columns() []string {
+	return r.columns
+}
+
+func (r *countingRows) Close() error {
+	return nil
+}
+
+func (r *countingRows) Next(dest []driver.Value) error {
+	if r.next >= r.n {
+		return io.EOF
+	}
+	r.next++
+	copy(dest, r.row(r.next))
+	return nil
+}
+
+// countQueries returns the number of queries DBModel.All runs to list n movies
+func countQueries(tb testing.TB, n int) int64 {
+	c := &countingConnector{movies: n}
+	db := sql.OpenDB(c)
+	defer db.Close()
+
+	m := &DBModel{DB: db}
+	movies, _, err := m.All(context.Background(), MovieFilter{})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if len(movies) != n {
+		tb.Fatalf("got %d movies, want %d", len(movies), n)
+	}
+	for _, movie := range movies {
+		if movie.MovieGenre[1] != "Drama" {
+			tb.Fatalf("movie %d has genres %v, want Drama", movie.ID, movie.MovieGenre)
+		}
+	}
+
+	return atomic.LoadInt64(&c.queries)
+}
+
+func TestAllQueryCountIsConstant(t *testing.T) {
+	for _, n := range []int{10, 100, 1000} {
+		if got := countQueries(t, n); got != 2 {
+			t.Errorf("listing %d movies ran %d queries, want 2", n, got)
+		}
+	}
+}
+
+func BenchmarkAll(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("movies=%d", n), func(b *testing.B) {
+			var queries int64
+			for i := 0; i < b.N; i++ {
+				queries = countQueries(b, n)
+				if queries != 2 {
+					b.Fatalf("listing %d movies ran %d queries, want 2", n, queries)
+				}
+			}
+			b.ReportMetric(float64(queries), "queries/op")
+		})
+	}
+}
@@ -0,0 +1,533 @@
+package models
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryModel is an in-memory implementation of MovieStore. It is safe for
+// concurrent use and is meant for local development and handler tests.
+type MemoryModel struct {
+	mu           sync.RWMutex
+	movies       map[int]*Movie
+	genres       map[int]*Genre
+	movieGenres  []MovieGenre
+	nextMovieID  int
+	nextGenreID  int
+	nextLinkID   int
+	auditLog     []*AuditEntry
+	nextAuditID  int
+	revisions    map[int][]*Revision
+	nextRevID    int
+	people       map[int]*Person
+	credits      map[int]*Credit
+	nextPersonID int
+	nextCreditID int
+	reviews      map[int]*Review
+	nextReviewID int
+	reviewTotals map[int]int // sum of review scores keyed by movie ID
+	lists        []*listEntry
+	nextListID   int
+	users        map[int]*User
+	nextUserID   int
+
+	refreshTokens []*RefreshToken
+	nextRefreshID int
+	deniedTokens  map[string]time.Time // access token expiry keyed by JWT ID
+
+	passwordResets []*PasswordReset
+	nextResetID    int
+	loginAttempts  map[string]*LoginAttempt
+}
+
+var _ MovieStore = (*MemoryModel)(nil)
+
+// NewMemoryModel returns an empty in-memory store
+func NewMemoryModel() *MemoryModel {
+	return &MemoryModel{
+		movies:       make(map[int]*Movie),
+		genres:       make(map[int]*Genre),
+		nextMovieID:  1,
+		nextGenreID:  1,
+		nextLinkID:   1,
+		nextAuditID:  1,
+		revisions:    make(map[int][]*Revision),
+		nextRevID:    1,
+		people:       make(map[int]*Person),
+		credits:      make(map[int]*Credit),
+		nextPersonID: 1,
+		nextCreditID: 1,
+		reviews:      make(map[int]*Review),
+		nextReviewID: 1,
+		reviewTotals: make(map[int]int),
+		nextListID:   1,
+		users:        make(map[int]*User),
+		nextUserID:   1,
+
+		nextRefreshID: 1,
+		deniedTokens:  make(map[string]time.Time),
+
+		nextResetID:   1,
+		loginAttempts: make(map[string]*LoginAttempt),
+	}
+}
+
+// Get returns one movie with its credits and an error, if any
+func (m *MemoryModel) Get(ctx context.Context, id int) (*Movie, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	movie, ok := m.movies[id]
+	if !ok || movie.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+
+	c := m.copyMovie(movie)
+	c.Credits = m.creditsFor(id)
+
+	return c, nil
+}
+
+// All returns one page of movies matching the filter, the cursor of the
+// next page (empty on the last page) and an error, if any
+func (m *MemoryModel) All(ctx context.Context, filter MovieFilter) ([]*Movie, string, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	key, desc := filter.sortKey()
+	less := func(a, b *Movie) bool {
+		if desc {
+			return compareMovies(a, b, key) > 0
+		}
+		return compareMovies(a, b, key) < 0
+	}
+
+	var after *Movie
+	if filter.Cursor != "" {
+		cursor, err := decodeCursor(filter.Cursor, key)
+		if err != nil {
+			return nil, "", err
+		}
+		after = cursor
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var movies []*Movie
+	for _, movie := range m.movies {
+		if movie.DeletedAt != nil || !filter.matches(movie, m.genreIDs(movie.ID)) {
+			continue
+		}
+		if after != nil && !less(after, movie) {
+			continue
+		}
+		movies = append(movies, m.copyMovie(movie))
+	}
+
+	sort.Slice(movies, func(i, j int) bool {
+		return less(movies[i], movies[j])
+	})
+
+	next := ""
+	if filter.Limit > 0 && len(movies) > filter.Limit {
+		movies = movies[:filter.Limit]
+		next = encodeCursor(movies[len(movies)-1], key)
+	}
+
+	return movies, next, nil
+}
+
+// Search returns the movies whose title or description match every word of
+// the query as a prefix, best matches first. Title matches rank above
+// description matches.
+func (m *MemoryModel) Search(ctx context.Context, q string, limit int) ([]*SearchResult, error) {
+	terms := searchTerms(q)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var results []*SearchResult
+	for _, movie := range m.movies {
+		if movie.DeletedAt != nil {
+			continue
+		}
+
+		_, inTitle, _ := highlight(movie.Title, terms)
+		_, inDescription, _ := highlight(movie.Description, terms)
+
+		rank, matchesAll := 0.0, true
+		for _, t := range terms {
+			if inTitle[t] {
+				rank += 1.0
+			} else if inDescription[t] {
+				rank += 0.4
+			} else {
+				matchesAll = false
+				break
+			}
+		}
+		if !matchesAll {
+			continue
+		}
+
+		results = append(results, &SearchResult{
+			Movie:   *m.copyMovie(movie),
+			Rank:    rank / float64(len(terms)),
+			Snippet: snippet(movie.Description, terms),
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return compareMovies(&results[i].Movie, &results[j].Movie, "title") < 0
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+// GenresAll returns all genres ordered by name
+func (m *MemoryModel) GenresAll(ctx context.Context) ([]*Genre, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var genres []*Genre
+	for _, genre := range m.genres {
+		g := *genre
+		genres = append(genres, &g)
+	}
+
+	sort.Slice(genres, func(i, j int) bool {
+		return genres[i].GenreName < genres[j].GenreName
+	})
+
+	return genres, nil
+}
+
+// InsertGenre inserts a genre and returns it with its new ID
+func (m *MemoryModel) InsertGenre(ctx context.Context, name string, actor Actor) (*Genre, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.genreNameTaken(name, 0) {
+		return nil, ErrDuplicateGenre
+	}
+
+	now := time.Now()
+	genre := Genre{
+		ID:        m.nextGenreID,
+		GenreName: name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	m.nextGenreID++
+	m.genres[genre.ID] = &genre
+
+	if err := m.audit(actor, ActionGenreCreate, "genre", genre.ID, nil, genre); err != nil {
+		return nil, err
+	}
+
+	g := genre
+	return &g, nil
+}
+
+// UpdateGenre renames a genre
+func (m *MemoryModel) UpdateGenre(ctx context.Context, genre Genre, actor Actor) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.genres[genre.ID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if m.genreNameTaken(genre.GenreName, genre.ID) {
+		return ErrDuplicateGenre
+	}
+
+	before := *existing
+	existing.GenreName = genre.GenreName
+	existing.UpdatedAt = time.Now()
+
+	return m.audit(actor, ActionGenreRename, "genre", genre.ID, before, *existing)
+}
+
+// DeleteGenre deletes a genre. Genres still assigned to a movie are not
+// deleted and ErrGenreInUse is returned instead.
+func (m *MemoryModel) DeleteGenre(ctx context.Context, id int, actor Actor) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	before, ok := m.genres[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	for _, mg := range m.movieGenres {
+		if mg.GenreID == id {
+			return ErrGenreInUse
+		}
+	}
+
+	delete(m.genres, id)
+
+	return m.audit(actor, ActionGenreDelete, "genre", id, *before, nil)
+}
+
+// InsertMovie inserts a movie into the store and links it to the genres
+// keyed in movie.MovieGenre. It returns the new movie ID.
+func (m *MemoryModel) InsertMovie(ctx context.Context, movie Movie, actor Actor) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	genreIDs := movie.GenreIDs()
+	if err := m.checkGenres(genreIDs); err != nil {
+		return 0, err
+	}
+
+	movie.ID = m.nextMovieID
+	movie.MovieGenre = nil
+	movie.Version = 1
+	m.nextMovieID++
+	m.movies[movie.ID] = &movie
+	m.replaceGenres(movie.ID, genreIDs)
+
+	if err := m.audit(actor, ActionMovieCreate, "movie", movie.ID, nil, m.copyMovie(&movie)); err != nil {
+		return 0, err
+	}
+
+	return movie.ID, nil
+}
+
+// UpdateMovie updates a movie in the store and replaces its genre links with
+// the genres keyed in movie.MovieGenre. The update only succeeds if
+// movie.Version is still the stored version; otherwise ErrEditConflict is
+// returned. The previous state is kept as a revision.
+func (m *MemoryModel) UpdateMovie(ctx context.Context, movie Movie, actor Actor) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.movies[movie.ID]
+	if !ok || existing.DeletedAt != nil {
+		return ErrNotFound
+	}
+	if existing.Version != movie.Version {
+		return ErrEditConflict
+	}
+
+	genreIDs := movie.GenreIDs()
+	if err := m.checkGenres(genreIDs); err != nil {
+		return err
+	}
+
+	before := m.copyMovie(existing)
+	m.revise(before, ActionMovieUpdate, actor)
+	assignMovie(existing, movie)
+	existing.UpdatedAt = movie.UpdatedAt
+	existing.Version++
+	m.replaceGenres(movie.ID, genreIDs)
+
+	return m.audit(actor, ActionMovieUpdate, "movie", movie.ID, before, m.copyMovie(existing))
+}
+
+// DeleteMovie moves a movie to the trash, keeping its last state as a
+// revision
+func (m *MemoryModel) DeleteMovie(ctx context.Context, id int, actor Actor) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	movie, ok := m.movies[id]
+	if !ok || movie.DeletedAt != nil {
+		return ErrNotFound
+	}
+
+	before := m.copyMovie(movie)
+	m.revise(before, ActionMovieDelete, actor)
+	now := time.Now()
+	movie.DeletedAt = &now
+	movie.UpdatedAt = now
+	movie.Version++
+
+	return m.audit(actor, ActionMovieDelete, "movie", id, before, m.copyMovie(movie))
+}
+
+// Trash returns the movies in the trash, most recently deleted first
+func (m *MemoryModel) Trash(ctx context.Context) ([]*Movie, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var movies []*Movie
+	for _, movie := range m.movies {
+		if movie.DeletedAt != nil {
+			movies = append(movies, m.copyMovie(movie))
+		}
+	}
+
+	sort.Slice(movies, func(i, j int) bool {
+		if !movies[i].DeletedAt.Equal(*movies[j].DeletedAt) {
+			return movies[i].DeletedAt.After(*movies[j].DeletedAt)
+		}
+		return movies[i].ID > movies[j].ID
+	})
+
+	return movies, nil
+}
+
+// RestoreMovie takes a movie back out of the trash
+func (m *MemoryModel) RestoreMovie(ctx context.Context, id int, actor Actor) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	movie, ok := m.movies[id]
+	if !ok || movie.DeletedAt == nil {
+		return ErrNotFound
+	}
+
+	before := m.copyMovie(movie)
+	movie.DeletedAt = nil
+	movie.UpdatedAt = time.Now()
+	movie.Version++
+
+	return m.audit(actor, ActionMovieRestore, "movie", id, before, m.copyMovie(movie))
+}
+
+// PurgeTrash permanently deletes the movies that were moved to the trash
+// before the given time and returns how many were deleted
+func (m *MemoryModel) PurgeTrash(ctx context.Context, before time.Time, actor Actor) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	purged := 0
+	for id, movie := range m.movies {
+		if movie.DeletedAt != nil && movie.DeletedAt.Before(before) {
+			snapshot := map[string]interface{}{"id": movie.ID, "title": movie.Title, "year": movie.Year, "deleted_at": movie.DeletedAt}
+			delete(m.movies, id)
+			delete(m.revisions, id)
+			delete(m.reviewTotals, id)
+			for reviewID, review := range m.reviews {
+				if review.MovieID == id {
+					delete(m.reviews, reviewID)
+				}
+			}
+			for creditID, credit := range m.credits {
+				if credit.MovieID == id {
+					delete(m.credits, creditID)
+				}
+			}
+			m.removeListEntries(id)
+			m.replaceGenres(id, nil)
+			if err := m.audit(actor, ActionMoviePurge, "movie", id, snapshot, nil); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// checkGenres returns ErrInvalidGenre if any of the genres does not exist.
+// The caller must hold m.mu.
+func (m *MemoryModel) checkGenres(genreIDs []int) error {
+	for _, id := range genreIDs {
+		if _, ok := m.genres[id]; !ok {
+			return ErrInvalidGenre
+		}
+	}
+	return nil
+}
+
+// replaceGenres replaces the genre links of a movie. The caller must hold m.mu.
+func (m *MemoryModel) replaceGenres(movieID int, genreIDs []int) {
+	links := m.movieGenres[:0]
+	for _, mg := range m.movieGenres {
+		if mg.MovieID != movieID {
+			links = append(links, mg)
+		}
+	}
+
+	now := time.Now()
+	for _, id := range genreIDs {
+		links = append(links, MovieGenre{
+			ID:        m.nextLinkID,
+			MovieID:   movieID,
+			GenreID:   id,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+		m.nextLinkID++
+	}
+
+	m.movieGenres = links
+}
+
+// genreNameTaken reports whether another genre than id already uses name,
+// ignoring case. The caller must hold m.mu.
+func (m *MemoryModel) genreNameTaken(name string, id int) bool {
+	for _, genre := range m.genres {
+		if genre.ID != id && strings.EqualFold(genre.GenreName, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// genreIDs returns the IDs of the genres linked to a movie. The caller must
+// hold m.mu.
+func (m *MemoryModel) genreIDs(movieID int) []int {
+	var ids []int
+	for _, mg := range m.movieGenres {
+		if mg.MovieID == movieID {
+			ids = append(ids, mg.GenreID)
+		}
+	}
+	return ids
+}
+
+// assignMovie copies the editable fields of src onto a stored movie
+func assignMovie(dst *Movie, src Movie) {
+	dst.Title = src.Title
+	dst.Description = src.Description
+	dst.Year = src.Year
+	dst.ReleaseDate = src.ReleaseDate
+	dst.Runtime = src.Runtime
+	dst.Rating = src.Rating
+	dst.MPAARating = src.MPAARating
+	dst.Poster = src.Poster
+}
+
+// copyMovie returns a copy of a stored movie with its genres filled in. The
+// caller must hold m.mu.
+func (m *MemoryModel) copyMovie(movie *Movie) *Movie {
+	c := *movie
+	if movie.DeletedAt != nil {
+		at := *movie.DeletedAt
+		c.DeletedAt = &at
+	}
+
+	genres := make(map[int]string)
+	for _, mg := range m.movieGenres {
+		if mg.MovieID != movie.ID {
+			continue
+		}
+		if genre, ok := m.genres[mg.GenreID]; ok {
+			genres[mg.GenreID] = genre.GenreName
+		}
+	}
+	c.MovieGenre = genres
+
+	return &c
+}
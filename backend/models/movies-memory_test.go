@@ -0,0 +1,134 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDeleteAndRestoreChangeVersion(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryModel()
+
+	id, err := m.InsertMovie(ctx, Movie{
+		Title:       "Casablanca",
+		Year:        1942,
+		ReleaseDate: time.Date(1942, 11, 26, 0, 0, 0, 0, time.UTC),
+	}, Actor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stale, err := m.Get(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = m.DeleteMovie(ctx, id, Actor{}); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.RestoreMovie(ctx, id, Actor{}); err != nil {
+		t.Fatal(err)
+	}
+
+	movie, err := m.Get(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if movie.Version != stale.Version+2 {
+		t.Errorf("version = %d after a delete and a restore, want %d", movie.Version, stale.Version+2)
+	}
+
+	stale.Title = "Casablanca (1942)"
+	if err = m.UpdateMovie(ctx, *stale, Actor{}); !errors.Is(err, ErrEditConflict) {
+		t.Errorf("edit based on the movie before it was deleted: got %v, want ErrEditConflict", err)
+	}
+}
+
+// TestMemoryModelConcurrentWriters is meant to be run with -race
+func TestMemoryModelConcurrentWriters(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryModel()
+
+	genre, err := m.InsertGenre(ctx, "Drama", Actor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const writers, perWriter = 8, 25
+
+	var wg sync.WaitGroup
+	ids := make(chan int, writers*perWriter)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				id, err := m.InsertMovie(ctx, Movie{
+					Title:       fmt.Sprintf("Movie %d-%d", w, i),
+					Year:        2000,
+					ReleaseDate: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+					MovieGenre:  map[int]string{genre.ID: ""},
+				}, Actor{UserID: w})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				ids <- id
+
+				movie, err := m.Get(ctx, id)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				movie.Runtime = 100 + i
+				if err = m.UpdateMovie(ctx, *movie, Actor{UserID: w}); err != nil {
+					t.Error(err)
+					return
+				}
+
+				if _, _, err = m.All(ctx, MovieFilter{}); err != nil {
+					t.Error(err)
+					return
+				}
+
+				// every other movie goes to the trash
+				if i%2 == 1 {
+					if err = m.DeleteMovie(ctx, id, Actor{UserID: w}); err != nil {
+						t.Error(err)
+						return
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[int]bool)
+	for id := range ids {
+		if seen[id] {
+			t.Fatalf("movie ID %d was handed out twice", id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != writers*perWriter {
+		t.Fatalf("inserted %d movies, want %d", len(seen), writers*perWriter)
+	}
+
+	movies, _, err := m.All(ctx, MovieFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := writers * (perWriter - perWriter/2); len(movies) != want {
+		t.Errorf("%d movies left, want %d", len(movies), want)
+	}
+	for _, movie := range movies {
+		if movie.Version != 2 || movie.MovieGenre[genre.ID] != "Drama" {
+			t.Errorf("movie %d has version %d and genres %v, want 2 and Drama", movie.ID, movie.Version, movie.MovieGenre)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// ErrInvalidCursor is returned when a page cursor cannot be decoded
+var ErrInvalidCursor = newError(ErrValidation, "invalid cursor")
+
+// Page selects one page of a keyset-paginated list. A zero Limit returns
+// every remaining row.
+type Page struct {
+	Limit  int
+	Cursor string
+}
+
+// movieCursor is the position after the last movie of a page: the value of
+// the sort key and the ID of that movie
+type movieCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// encodeCursor turns the last movie of a page into an opaque cursor
+func encodeCursor(movie *Movie, key string) string {
+	c := movieCursor{Sort: key, ID: movie.ID}
+	switch key {
+	case "release_date":
+		c.Value = movie.ReleaseDate.Format("2006-01-02")
+	case "rating":
+		c.Value = strconv.Itoa(movie.Rating)
+	case "runtime":
+		c.Value = strconv.Itoa(movie.Runtime)
+	default:
+		c.Value = movie.Title
+	}
+
+	js, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(js)
+}
+
+// decodeCursor parses a cursor produced by encodeCursor for the same sort key
+// and returns a movie holding only the sort value and ID
+func decodeCursor(cursor, key string) (*Movie, error) {
+	js, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c movieCursor
+	if err = json.Unmarshal(js, &c); err != nil || c.ID <= 0 || c.Sort != key {
+		return nil, ErrInvalidCursor
+	}
+
+	movie := Movie{ID: c.ID}
+	switch key {
+	case "release_date":
+		movie.ReleaseDate, err = time.Parse("2006-01-02", c.Value)
+	case "rating":
+		movie.Rating, err = strconv.Atoi(c.Value)
+	case "runtime":
+		movie.Runtime, err = strconv.Atoi(c.Value)
+	default:
+		movie.Title = c.Value
+	}
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &movie, nil
+}
+
+// sortValue returns the value of a movie's sort key as a query argument
+func sortValue(movie *Movie, key string) interface{} {
+	switch key {
+	case "release_date":
+		return movie.ReleaseDate
+	case "rating":
+		return movie.Rating
+	case "runtime":
+		return movie.Runtime
+	}
+	return movie.Title
+}
+
+// encodeIDCursor turns the ID of the last row of a page into an opaque cursor
+// for lists ordered by ID
+func encodeIDCursor(id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(id)))
+}
+
+// decodeIDCursor parses a cursor produced by encodeIDCursor
+func decodeIDCursor(cursor string) (int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	id, err := strconv.Atoi(string(b))
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidCursor
+	}
+
+	return id, nil
+}
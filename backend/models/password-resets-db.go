@@ -0,0 +1,114 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// InsertPasswordReset stores a reset token for a user, replacing the
+// user's earlier ones
+func (m *DBModel) InsertPasswordReset(ctx context.Context, reset PasswordReset) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM password_resets WHERE user_id = $1", reset.UserID); err != nil {
+			return err
+		}
+
+		stmt := `
+		INSERT INTO
+			password_resets (user_id, token_hash, expires_at, created_at)
+		VALUES
+			($1, $2, $3, $4)
+		`
+
+		_, err := tx.ExecContext(ctx, stmt, reset.UserID, reset.Hash, reset.ExpiresAt, time.Now())
+		return err
+	})
+}
+
+// PasswordResetUser returns the user a usable reset token was issued to, or
+// ErrInvalidResetToken
+func (m *DBModel) PasswordResetUser(ctx context.Context, hash []byte) (*User, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	reset, err := getPasswordReset(ctx, m.DB, hash, false)
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	user, err := getUser(ctx, m.DB, reset.UserID)
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	return user, nil
+}
+
+// ResetPassword uses up a reset token, sets the password hash of its user
+// and revokes the user's refresh tokens so that every session has to log in
+// again
+func (m *DBModel) ResetPassword(ctx context.Context, hash []byte, passwordHash string) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		reset, err := getPasswordReset(ctx, tx, hash, true)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if _, err = tx.ExecContext(ctx, "UPDATE password_resets SET used_at = $1 WHERE id = $2", now, reset.ID); err != nil {
+			return err
+		}
+
+		if err = updatePassword(ctx, tx, reset.UserID, passwordHash, now); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL", now, reset.UserID)
+		return err
+	})
+}
+
+// getPasswordReset returns the usable reset token with the given hash,
+// optionally locking it for update, or ErrInvalidResetToken
+func getPasswordReset(ctx context.Context, q queryer, hash []byte, lock bool) (*PasswordReset, error) {
+	query := `
+	SELECT
+		id, user_id, token_hash, expires_at, used_at, created_at
+	FROM
+		password_resets
+	WHERE
+		token_hash = $1
+	`
+	if lock {
+		query += " FOR UPDATE"
+	}
+
+	var reset PasswordReset
+	err := q.QueryRowContext(ctx, query, hash).Scan(
+		&reset.ID,
+		&reset.UserID,
+		&reset.Hash,
+		&reset.ExpiresAt,
+		&reset.UsedAt,
+		&reset.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidResetToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !reset.usable(time.Now()) {
+		return nil, ErrInvalidResetToken
+	}
+
+	return &reset, nil
+}
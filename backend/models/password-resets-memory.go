@@ -0,0 +1,87 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"time"
+)
+
+// InsertPasswordReset stores a reset token for a user, replacing the
+// user's earlier ones
+func (m *MemoryModel) InsertPasswordReset(ctx context.Context, reset PasswordReset) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	resets := m.passwordResets[:0]
+	for _, r := range m.passwordResets {
+		if r.UserID != reset.UserID {
+			resets = append(resets, r)
+		}
+	}
+
+	reset.ID = m.nextResetID
+	reset.CreatedAt = time.Now()
+	m.nextResetID++
+	m.passwordResets = append(resets, &reset)
+
+	return nil
+}
+
+// PasswordResetUser returns the user a usable reset token was issued to, or
+// ErrInvalidResetToken
+func (m *MemoryModel) PasswordResetUser(ctx context.Context, hash []byte) (*User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	reset := m.findPasswordReset(hash)
+	if reset == nil {
+		return nil, ErrInvalidResetToken
+	}
+
+	user, ok := m.users[reset.UserID]
+	if !ok {
+		return nil, ErrInvalidResetToken
+	}
+
+	u := *user
+	return &u, nil
+}
+
+// ResetPassword uses up a reset token, sets the password hash of its user
+// and revokes the user's refresh tokens so that every session has to log in
+// again
+func (m *MemoryModel) ResetPassword(ctx context.Context, hash []byte, passwordHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	reset := m.findPasswordReset(hash)
+	if reset == nil {
+		return ErrInvalidResetToken
+	}
+
+	now := time.Now()
+	if err := m.setPassword(reset.UserID, passwordHash, now); err != nil {
+		return err
+	}
+	reset.UsedAt = &now
+
+	for _, token := range m.refreshTokens {
+		if token.UserID == reset.UserID && token.RevokedAt == nil {
+			revoked := now
+			token.RevokedAt = &revoked
+		}
+	}
+
+	return nil
+}
+
+// findPasswordReset returns the usable reset token with the given hash, or
+// nil. The caller must hold m.mu.
+func (m *MemoryModel) findPasswordReset(hash []byte) *PasswordReset {
+	for _, reset := range m.passwordResets {
+		if bytes.Equal(reset.Hash, hash) && reset.usable(time.Now()) {
+			return reset
+		}
+	}
+	return nil
+}
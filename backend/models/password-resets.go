@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// PasswordResetTTL is how long a password reset token can be used
+const PasswordResetTTL = time.Hour
+
+// ErrInvalidResetToken is returned for a password reset token that is
+// unknown, expired or already used
+var ErrInvalidResetToken = newError(ErrValidation, "invalid or expired reset token")
+
+// PasswordReset is the stored form of a password reset token. Only the hash
+// of the token is kept; the token itself is emailed to the user.
+type PasswordReset struct {
+	ID        int
+	UserID    int
+	Hash      []byte
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// usable reports whether a reset token can still be used
+func (p *PasswordReset) usable(now time.Time) bool {
+	return p.UsedAt == nil && p.ExpiresAt.After(now)
+}
+
+// IPResetKey returns the key under which password reset requests from an IP
+// are counted
+func IPResetKey(ip string) string {
+	return "reset-ip:" + ip
+}
+
+// AccountResetKey returns the key under which password reset requests for an
+// email address are counted, whether or not it is registered
+func AccountResetKey(email string) string {
+	return "reset-account:" + NormalizeEmail(email)
+}
@@ -0,0 +1,406 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// People returns one page of people ordered by ID, only those whose name
+// contains name (ignoring case) when it is not empty, the cursor of the next
+// page (empty on the last page) and an error, if any
+func (m *DBModel) People(ctx context.Context, name string, page Page) ([]*Person, string, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	args := []interface{}{name}
+	where := "WHERE ($1 = '' OR strpos(lower(name), lower($1)) > 0)"
+	if page.Cursor != "" {
+		after, err := decodeIDCursor(page.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		args = append(args, after)
+		where += " AND id > $2"
+	}
+
+	limit := ""
+	if page.Limit > 0 {
+		args = append(args, page.Limit+1)
+		limit = fmt.Sprintf("LIMIT $%d", len(args))
+	}
+
+	query := fmt.Sprintf(`
+	SELECT
+		id, name, birth_date, biography, created_at, updated_at
+	FROM
+		people %s
+	ORDER BY
+		id
+	%s
+	`, where, limit)
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", dbError(err)
+	}
+	defer rows.Close()
+
+	var people []*Person
+	for rows.Next() {
+		person, err := scanPerson(rows)
+		if err != nil {
+			return nil, "", dbError(err)
+		}
+		people = append(people, person)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", dbError(err)
+	}
+
+	next := ""
+	if page.Limit > 0 && len(people) > page.Limit {
+		people = people[:page.Limit]
+		next = encodeIDCursor(people[len(people)-1].ID)
+	}
+
+	return people, next, nil
+}
+
+// Person returns one person with their filmography, newest movies first.
+// Movies in the trash are left out of the filmography.
+func (m *DBModel) Person(ctx context.Context, id int) (*Person, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	person, err := getPerson(ctx, m.DB, id, false)
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	query := `
+	SELECT
+		c.id, c.movie_id, c.person_id, c.role, c.character_name, c.billing_order, m.title, m.year
+	FROM
+		movie_credits c
+		JOIN movies m ON (m.id = c.movie_id)
+	WHERE
+		c.person_id = $1 AND m.deleted_at IS NULL
+	ORDER BY
+		m.release_date DESC, m.id, c.billing_order
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c Credit
+		err := rows.Scan(&c.ID, &c.MovieID, &c.PersonID, &c.Role, &c.Character, &c.BillingOrder, &c.MovieTitle, &c.MovieYear)
+		if err != nil {
+			return nil, dbError(err)
+		}
+		person.Credits = append(person.Credits, &c)
+	}
+
+	return person, dbError(rows.Err())
+}
+
+// InsertPerson inserts a person and returns them with their new ID
+func (m *DBModel) InsertPerson(ctx context.Context, person Person, actor Actor) (*Person, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	err := m.withTx(ctx, func(tx *sql.Tx) error {
+		stmt := `
+		INSERT INTO
+			people (name, birth_date, biography, created_at, updated_at)
+		VALUES
+			($1, $2, $3, $4, $4)
+		RETURNING
+			id, created_at, updated_at
+		`
+
+		err := tx.QueryRowContext(ctx, stmt, person.Name, person.BirthDate, person.Biography, time.Now()).Scan(
+			&person.ID,
+			&person.CreatedAt,
+			&person.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		return insertAudit(ctx, tx, actor, ActionPersonCreate, "person", person.ID, nil, person)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &person, nil
+}
+
+// UpdatePerson updates the name, birth date and biography of a person
+func (m *DBModel) UpdatePerson(ctx context.Context, person Person, actor Actor) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		before, err := getPerson(ctx, tx, person.ID, true)
+		if err != nil {
+			return err
+		}
+
+		stmt := `
+		UPDATE
+			people
+		SET
+			name = $1, birth_date = $2, biography = $3, updated_at = $4
+		WHERE
+			id = $5
+		`
+
+		_, err = tx.ExecContext(ctx, stmt, person.Name, person.BirthDate, person.Biography, time.Now(), person.ID)
+		if err != nil {
+			return err
+		}
+
+		return insertAudit(ctx, tx, actor, ActionPersonUpdate, "person", person.ID, before, person)
+	})
+}
+
+// DeletePerson deletes a person. People still credited on a movie are not
+// deleted and ErrPersonCredited is returned instead.
+func (m *DBModel) DeletePerson(ctx context.Context, id int, actor Actor) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		before, err := getPerson(ctx, tx, id, true)
+		if err != nil {
+			return err
+		}
+
+		if _, err = tx.ExecContext(ctx, "DELETE FROM people WHERE id = $1", id); err != nil {
+			if isPQError(err, pqForeignKeyViolation) {
+				return ErrPersonCredited
+			}
+			return err
+		}
+
+		return insertAudit(ctx, tx, actor, ActionPersonDelete, "person", id, before, nil)
+	})
+}
+
+// InsertCredit credits a person on a movie and returns the credit with its
+// new ID
+func (m *DBModel) InsertCredit(ctx context.Context, credit Credit, actor Actor) (*Credit, error) {
+	if err := credit.Validate(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	err := m.withTx(ctx, func(tx *sql.Tx) error {
+		movie, err := getMovie(ctx, tx, credit.MovieID, false)
+		if err != nil {
+			return err
+		}
+		if movie.DeletedAt != nil {
+			return ErrNotFound
+		}
+
+		stmt := `
+		INSERT INTO
+			movie_credits (movie_id, person_id, role, character_name, billing_order, created_at, updated_at)
+		VALUES
+			($1, $2, $3, $4, $5, $6, $6)
+		RETURNING
+			id
+		`
+
+		err = tx.QueryRowContext(ctx, stmt,
+			credit.MovieID,
+			credit.PersonID,
+			credit.Role,
+			credit.Character,
+			credit.BillingOrder,
+			time.Now(),
+		).Scan(&credit.ID)
+		if err != nil {
+			if isPQError(err, pqForeignKeyViolation) {
+				return ErrInvalidPerson
+			}
+			return err
+		}
+
+		return insertAudit(ctx, tx, actor, ActionCreditCreate, "credit", credit.ID, nil, credit)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &credit, nil
+}
+
+// UpdateCredit changes the role, character and billing order of a credit
+func (m *DBModel) UpdateCredit(ctx context.Context, credit Credit, actor Actor) error {
+	if err := credit.Validate(); err != nil {
+		return err
+	}
+
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		before, err := getCredit(ctx, tx, credit.ID)
+		if err != nil {
+			return err
+		}
+
+		stmt := `
+		UPDATE
+			movie_credits
+		SET
+			role = $1, character_name = $2, billing_order = $3, updated_at = $4
+		WHERE
+			id = $5
+		`
+
+		_, err = tx.ExecContext(ctx, stmt, credit.Role, credit.Character, credit.BillingOrder, time.Now(), credit.ID)
+		if err != nil {
+			return err
+		}
+
+		after := *before
+		after.Role, after.Character, after.BillingOrder = credit.Role, credit.Character, credit.BillingOrder
+
+		return insertAudit(ctx, tx, actor, ActionCreditUpdate, "credit", credit.ID, before, after)
+	})
+}
+
+// DeleteCredit removes a credit
+func (m *DBModel) DeleteCredit(ctx context.Context, id int, actor Actor) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		before, err := getCredit(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if _, err = tx.ExecContext(ctx, "DELETE FROM movie_credits WHERE id = $1", id); err != nil {
+			return err
+		}
+
+		return insertAudit(ctx, tx, actor, ActionCreditDelete, "credit", id, before, nil)
+	})
+}
+
+// creditsFor returns the credits of a movie in billing order, with the
+// names of the people credited
+func creditsFor(ctx context.Context, q queryer, movieID int) ([]*Credit, error) {
+	query := `
+	SELECT
+		c.id, c.movie_id, c.person_id, c.role, c.character_name, c.billing_order, p.name
+	FROM
+		movie_credits c
+		JOIN people p ON (p.id = c.person_id)
+	WHERE
+		c.movie_id = $1
+	ORDER BY
+		c.billing_order, c.id
+	`
+
+	rows, err := q.QueryContext(ctx, query, movieID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var credits []*Credit
+	for rows.Next() {
+		var c Credit
+		err := rows.Scan(&c.ID, &c.MovieID, &c.PersonID, &c.Role, &c.Character, &c.BillingOrder, &c.PersonName)
+		if err != nil {
+			return nil, err
+		}
+		credits = append(credits, &c)
+	}
+
+	return credits, rows.Err()
+}
+
+// getPerson returns one person. With lock set the row is locked for the rest
+// of the transaction.
+func getPerson(ctx context.Context, q queryer, id int, lock bool) (*Person, error) {
+	query := `
+	SELECT
+		id, name, birth_date, biography, created_at, updated_at
+	FROM
+		people
+	WHERE
+		id = $1
+	`
+	if lock {
+		query += " FOR UPDATE"
+	}
+
+	rows, err := q.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, ErrNotFound
+	}
+
+	return scanPerson(rows)
+}
+
+// getCredit returns one credit and locks it for the rest of the transaction
+func getCredit(ctx context.Context, tx *sql.Tx, id int) (*Credit, error) {
+	query := `
+	SELECT
+		id, movie_id, person_id, role, character_name, billing_order
+	FROM
+		movie_credits
+	WHERE
+		id = $1
+	FOR UPDATE
+	`
+
+	var c Credit
+	err := tx.QueryRowContext(ctx, query, id).Scan(&c.ID, &c.MovieID, &c.PersonID, &c.Role, &c.Character, &c.BillingOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// scanPerson reads a person from the current row
+func scanPerson(rows *sql.Rows) (*Person, error) {
+	var p Person
+	err := rows.Scan(
+		&p.ID,
+		&p.Name,
+		&p.BirthDate,
+		&p.Biography,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
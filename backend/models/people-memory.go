@@ -0,0 +1,235 @@
+package models
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+// People returns one page of people ordered by ID, only those whose name
+// contains name (ignoring case) when it is not empty, the cursor of the next
+// page (empty on the last page) and an error, if any
+func (m *MemoryModel) People(ctx context.Context, name string, page Page) ([]*Person, string, error) {
+	after := 0
+	if page.Cursor != "" {
+		id, err := decodeIDCursor(page.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		after = id
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var people []*Person
+	for _, person := range m.people {
+		if person.ID <= after || !strings.Contains(strings.ToLower(person.Name), strings.ToLower(name)) {
+			continue
+		}
+		p := *person
+		people = append(people, &p)
+	}
+
+	sort.Slice(people, func(i, j int) bool {
+		return people[i].ID < people[j].ID
+	})
+
+	next := ""
+	if page.Limit > 0 && len(people) > page.Limit {
+		people = people[:page.Limit]
+		next = encodeIDCursor(people[len(people)-1].ID)
+	}
+
+	return people, next, nil
+}
+
+// Person returns one person with their filmography, newest movies first.
+// Movies in the trash are left out of the filmography.
+func (m *MemoryModel) Person(ctx context.Context, id int) (*Person, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	person, ok := m.people[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	p := *person
+	for _, credit := range m.credits {
+		movie, ok := m.movies[credit.MovieID]
+		if credit.PersonID != id || !ok || movie.DeletedAt != nil {
+			continue
+		}
+		c := *credit
+		c.MovieTitle, c.MovieYear = movie.Title, movie.Year
+		p.Credits = append(p.Credits, &c)
+	}
+
+	sort.Slice(p.Credits, func(i, j int) bool {
+		a, b := m.movies[p.Credits[i].MovieID], m.movies[p.Credits[j].MovieID]
+		if !a.ReleaseDate.Equal(b.ReleaseDate) {
+			return a.ReleaseDate.After(b.ReleaseDate)
+		}
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		return p.Credits[i].BillingOrder < p.Credits[j].BillingOrder
+	})
+
+	return &p, nil
+}
+
+// InsertPerson inserts a person and returns them with their new ID
+func (m *MemoryModel) InsertPerson(ctx context.Context, person Person, actor Actor) (*Person, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	person.ID = m.nextPersonID
+	person.CreatedAt, person.UpdatedAt = now, now
+	person.Credits = nil
+	m.nextPersonID++
+	m.people[person.ID] = &person
+
+	if err := m.audit(actor, ActionPersonCreate, "person", person.ID, nil, person); err != nil {
+		return nil, err
+	}
+
+	p := person
+	return &p, nil
+}
+
+// UpdatePerson updates the name, birth date and biography of a person
+func (m *MemoryModel) UpdatePerson(ctx context.Context, person Person, actor Actor) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.people[person.ID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	before := *existing
+	existing.Name = person.Name
+	existing.BirthDate = person.BirthDate
+	existing.Biography = person.Biography
+	existing.UpdatedAt = time.Now()
+
+	return m.audit(actor, ActionPersonUpdate, "person", person.ID, before, *existing)
+}
+
+// DeletePerson deletes a person. People still credited on a movie are not
+// deleted and ErrPersonCredited is returned instead.
+func (m *MemoryModel) DeletePerson(ctx context.Context, id int, actor Actor) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	before, ok := m.people[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	for _, credit := range m.credits {
+		if credit.PersonID == id {
+			return ErrPersonCredited
+		}
+	}
+
+	delete(m.people, id)
+
+	return m.audit(actor, ActionPersonDelete, "person", id, *before, nil)
+}
+
+// InsertCredit credits a person on a movie and returns the credit with its
+// new ID
+func (m *MemoryModel) InsertCredit(ctx context.Context, credit Credit, actor Actor) (*Credit, error) {
+	if err := credit.Validate(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if movie, ok := m.movies[credit.MovieID]; !ok || movie.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+	if _, ok := m.people[credit.PersonID]; !ok {
+		return nil, ErrInvalidPerson
+	}
+
+	credit.ID = m.nextCreditID
+	credit.PersonName, credit.MovieTitle, credit.MovieYear = "", "", 0
+	m.nextCreditID++
+	m.credits[credit.ID] = &credit
+
+	if err := m.audit(actor, ActionCreditCreate, "credit", credit.ID, nil, credit); err != nil {
+		return nil, err
+	}
+
+	c := credit
+	return &c, nil
+}
+
+// UpdateCredit changes the role, character and billing order of a credit
+func (m *MemoryModel) UpdateCredit(ctx context.Context, credit Credit, actor Actor) error {
+	if err := credit.Validate(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.credits[credit.ID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	before := *existing
+	existing.Role = credit.Role
+	existing.Character = credit.Character
+	existing.BillingOrder = credit.BillingOrder
+
+	return m.audit(actor, ActionCreditUpdate, "credit", credit.ID, before, *existing)
+}
+
+// DeleteCredit removes a credit
+func (m *MemoryModel) DeleteCredit(ctx context.Context, id int, actor Actor) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	before, ok := m.credits[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	delete(m.credits, id)
+
+	return m.audit(actor, ActionCreditDelete, "credit", id, *before, nil)
+}
+
+// creditsFor returns the credits of a movie in billing order, with the
+// names of the people credited. The caller must hold m.mu.
+func (m *MemoryModel) creditsFor(movieID int) []*Credit {
+	var credits []*Credit
+	for _, credit := range m.credits {
+		if credit.MovieID != movieID {
+			continue
+		}
+		c := *credit
+		if person, ok := m.people[c.PersonID]; ok {
+			c.PersonName = person.Name
+		}
+		credits = append(credits, &c)
+	}
+
+	sort.Slice(credits, func(i, j int) bool {
+		if credits[i].BillingOrder != credits[j].BillingOrder {
+			return credits[i].BillingOrder < credits[j].BillingOrder
+		}
+		return credits[i].ID < credits[j].ID
+	})
+
+	return credits
+}
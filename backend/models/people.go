@@ -0,0 +1,71 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Audit actions of people and credits
+const (
+	ActionPersonCreate = "person.create"
+	ActionPersonUpdate = "person.update"
+	ActionPersonDelete = "person.delete"
+	ActionCreditCreate = "credit.create"
+	ActionCreditUpdate = "credit.update"
+	ActionCreditDelete = "credit.delete"
+)
+
+var (
+	// ErrPersonCredited is returned when deleting a person who is still
+	// credited on a movie
+	ErrPersonCredited = newError(ErrConflict, "person is still credited on movies")
+	// ErrInvalidPerson is returned when a credit names a person that does
+	// not exist
+	ErrInvalidPerson = newError(ErrValidation, "unknown person")
+)
+
+// CreditRoles are the roles a person can be credited with
+var CreditRoles = []string{"director", "writer", "producer", "actor"}
+
+// Person is the type for a member of the cast or crew
+type Person struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	BirthDate *time.Time `json:"birth_date"`
+	Biography string     `json:"biography"`
+	CreatedAt time.Time  `json:"-"`
+	UpdatedAt time.Time  `json:"-"`
+	Credits   []*Credit  `json:"filmography,omitempty"`
+}
+
+// Credit links a person to a movie in a role. Credits listed with a movie
+// carry the person's name; credits listed with a person carry the movie's
+// title and year.
+type Credit struct {
+	ID           int    `json:"id"`
+	MovieID      int    `json:"movie_id"`
+	PersonID     int    `json:"person_id"`
+	Role         string `json:"role"`
+	Character    string `json:"character,omitempty"`
+	BillingOrder int    `json:"billing_order"`
+	PersonName   string `json:"name,omitempty"`
+	MovieTitle   string `json:"title,omitempty"`
+	MovieYear    int    `json:"year,omitempty"`
+}
+
+// Validate checks the role and billing order of a credit
+func (c *Credit) Validate() error {
+	valid := false
+	for _, role := range CreditRoles {
+		if c.Role == role {
+			valid = true
+		}
+	}
+	if !valid {
+		return validationErrorf("role must be one of %s", strings.Join(CreditRoles, ", "))
+	}
+	if c.BillingOrder < 0 {
+		return validationErrorf("billing_order must not be negative")
+	}
+	return nil
+}
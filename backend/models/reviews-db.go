@@ -0,0 +1,208 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Reviews returns one page of the reviews of a movie, newest first, the
+// cursor of the next page (empty on the last page) and an error, if any
+func (m *DBModel) Reviews(ctx context.Context, movieID int, page Page) ([]*Review, string, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	movie, err := getMovie(ctx, m.DB, movieID, false)
+	if err != nil {
+		return nil, "", dbError(err)
+	}
+	if movie.DeletedAt != nil {
+		return nil, "", ErrNotFound
+	}
+
+	args := []interface{}{movieID}
+	where := "WHERE movie_id = $1"
+	if page.Cursor != "" {
+		before, err := decodeIDCursor(page.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		args = append(args, before)
+		where += " AND id < $2"
+	}
+
+	limit := ""
+	if page.Limit > 0 {
+		args = append(args, page.Limit+1)
+		limit = fmt.Sprintf("LIMIT $%d", len(args))
+	}
+
+	query := fmt.Sprintf(`
+	SELECT
+		id, movie_id, user_id, score, body, created_at, updated_at
+	FROM
+		reviews %s
+	ORDER BY
+		id DESC
+	%s
+	`, where, limit)
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", dbError(err)
+	}
+	defer rows.Close()
+
+	var reviews []*Review
+	for rows.Next() {
+		var r Review
+		err := rows.Scan(&r.ID, &r.MovieID, &r.UserID, &r.Score, &r.Body, &r.CreatedAt, &r.UpdatedAt)
+		if err != nil {
+			return nil, "", dbError(err)
+		}
+		reviews = append(reviews, &r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", dbError(err)
+	}
+
+	next := ""
+	if page.Limit > 0 && len(reviews) > page.Limit {
+		reviews = reviews[:page.Limit]
+		next = encodeIDCursor(reviews[len(reviews)-1].ID)
+	}
+
+	return reviews, next, nil
+}
+
+// InsertReview adds a user's review of a movie and returns it with its new
+// ID. A second review of the same movie by the same user is rejected with
+// ErrDuplicateReview.
+func (m *DBModel) InsertReview(ctx context.Context, review Review) (*Review, error) {
+	if err := review.Validate(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	err := m.withTx(ctx, func(tx *sql.Tx) error {
+		if err := addAudienceScore(ctx, tx, review.MovieID, 1, review.Score); err != nil {
+			return err
+		}
+
+		stmt := `
+		INSERT INTO
+			reviews (movie_id, user_id, score, body, created_at, updated_at)
+		VALUES
+			($1, $2, $3, $4, $5, $5)
+		RETURNING
+			id, created_at, updated_at
+		`
+
+		err := tx.QueryRowContext(ctx, stmt, review.MovieID, review.UserID, review.Score, review.Body, time.Now()).Scan(
+			&review.ID,
+			&review.CreatedAt,
+			&review.UpdatedAt,
+		)
+		if isPQError(err, pqUniqueViolation) {
+			return ErrDuplicateReview
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+// UpdateReview changes the score and text of a user's review of a movie
+func (m *DBModel) UpdateReview(ctx context.Context, review Review) (*Review, error) {
+	if err := review.Validate(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	err := m.withTx(ctx, func(tx *sql.Tx) error {
+		var previous int
+		err := tx.QueryRowContext(ctx, "SELECT score FROM reviews WHERE movie_id = $1 AND user_id = $2 FOR UPDATE", review.MovieID, review.UserID).Scan(&previous)
+		if err != nil {
+			return err
+		}
+
+		stmt := `
+		UPDATE
+			reviews
+		SET
+			score = $1, body = $2, updated_at = $3
+		WHERE
+			movie_id = $4 AND user_id = $5
+		RETURNING
+			id, created_at, updated_at
+		`
+
+		err = tx.QueryRowContext(ctx, stmt, review.Score, review.Body, time.Now(), review.MovieID, review.UserID).Scan(
+			&review.ID,
+			&review.CreatedAt,
+			&review.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		return addAudienceScore(ctx, tx, review.MovieID, 0, review.Score-previous)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+// DeleteReview removes a user's review of a movie
+func (m *DBModel) DeleteReview(ctx context.Context, movieID, userID int) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		var score int
+		err := tx.QueryRowContext(ctx, "DELETE FROM reviews WHERE movie_id = $1 AND user_id = $2 RETURNING score", movieID, userID).Scan(&score)
+		if err != nil {
+			return err
+		}
+
+		return addAudienceScore(ctx, tx, movieID, -1, -score)
+	})
+}
+
+// addAudienceScore adjusts the review count and score total of a movie that
+// is not in the trash
+func addAudienceScore(ctx context.Context, tx *sql.Tx, movieID, count, score int) error {
+	stmt := `
+	UPDATE
+		movies
+	SET
+		audience_count = audience_count + $1, audience_total = audience_total + $2
+	WHERE
+		id = $3 AND deleted_at IS NULL
+	`
+
+	result, err := tx.ExecContext(ctx, stmt, count, score, movieID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Reviews returns one page of the reviews of a movie, newest first, the
+// cursor of the next page (empty on the last page) and an error, if any
+func (m *MemoryModel) Reviews(ctx context.Context, movieID int, page Page) ([]*Review, string, error) {
+	before := 0
+	if page.Cursor != "" {
+		id, err := decodeIDCursor(page.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		before = id
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if movie, ok := m.movies[movieID]; !ok || movie.DeletedAt != nil {
+		return nil, "", ErrNotFound
+	}
+
+	var reviews []*Review
+	for _, review := range m.reviews {
+		if review.MovieID != movieID || (before > 0 && review.ID >= before) {
+			continue
+		}
+		r := *review
+		reviews = append(reviews, &r)
+	}
+
+	sort.Slice(reviews, func(i, j int) bool {
+		return reviews[i].ID > reviews[j].ID
+	})
+
+	next := ""
+	if page.Limit > 0 && len(reviews) > page.Limit {
+		reviews = reviews[:page.Limit]
+		next = encodeIDCursor(reviews[len(reviews)-1].ID)
+	}
+
+	return reviews, next, nil
+}
+
+// InsertReview adds a user's review of a movie and returns it with its new
+// ID. A second review of the same movie by the same user is rejected with
+// ErrDuplicateReview.
+func (m *MemoryModel) InsertReview(ctx context.Context, review Review) (*Review, error) {
+	if err := review.Validate(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if movie, ok := m.movies[review.MovieID]; !ok || movie.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+	if m.findReview(review.MovieID, review.UserID) != nil {
+		return nil, ErrDuplicateReview
+	}
+
+	now := time.Now()
+	review.ID = m.nextReviewID
+	review.CreatedAt, review.UpdatedAt = now, now
+	m.nextReviewID++
+	m.reviews[review.ID] = &review
+	m.addAudienceScore(review.MovieID, 1, review.Score)
+
+	r := review
+	return &r, nil
+}
+
+// UpdateReview changes the score and text of a user's review of a movie
+func (m *MemoryModel) UpdateReview(ctx context.Context, review Review) (*Review, error) {
+	if err := review.Validate(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if movie, ok := m.movies[review.MovieID]; !ok || movie.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+	existing := m.findReview(review.MovieID, review.UserID)
+	if existing == nil {
+		return nil, ErrNotFound
+	}
+
+	m.addAudienceScore(review.MovieID, 0, review.Score-existing.Score)
+	existing.Score = review.Score
+	existing.Body = review.Body
+	existing.UpdatedAt = time.Now()
+
+	r := *existing
+	return &r, nil
+}
+
+// DeleteReview removes a user's review of a movie
+func (m *MemoryModel) DeleteReview(ctx context.Context, movieID, userID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if movie, ok := m.movies[movieID]; !ok || movie.DeletedAt != nil {
+		return ErrNotFound
+	}
+	review := m.findReview(movieID, userID)
+	if review == nil {
+		return ErrNotFound
+	}
+
+	delete(m.reviews, review.ID)
+	m.addAudienceScore(movieID, -1, -review.Score)
+
+	return nil
+}
+
+// findReview returns a user's review of a movie, or nil. The caller must
+// hold m.mu.
+func (m *MemoryModel) findReview(movieID, userID int) *Review {
+	for _, review := range m.reviews {
+		if review.MovieID == movieID && review.UserID == userID {
+			return review
+		}
+	}
+	return nil
+}
+
+// addAudienceScore adjusts the review count and average score of a movie.
+// The caller must hold m.mu for writing.
+func (m *MemoryModel) addAudienceScore(movieID, count, score int) {
+	movie := m.movies[movieID]
+	m.reviewTotals[movieID] += score
+	movie.AudienceCount += count
+	movie.AudienceRating = audienceRating(m.reviewTotals[movieID], movie.AudienceCount)
+}
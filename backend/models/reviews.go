@@ -0,0 +1,48 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// Review scores range from MinReviewScore to MaxReviewScore
+const (
+	MinReviewScore = 1
+	MaxReviewScore = 5
+	maxReviewBody  = 10000
+)
+
+// ErrDuplicateReview is returned when a user reviews a movie twice
+var ErrDuplicateReview = newError(ErrConflict, "you have already reviewed this movie")
+
+// Review is one user's score and opinion of a movie. Each user can review a
+// movie once.
+type Review struct {
+	ID        int       `json:"id"`
+	MovieID   int       `json:"movie_id"`
+	UserID    int       `json:"user_id"`
+	Score     int       `json:"score"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate checks the score and length of a review
+func (r *Review) Validate() error {
+	if r.Score < MinReviewScore || r.Score > MaxReviewScore {
+		return validationErrorf("score must be from %d to %d", MinReviewScore, MaxReviewScore)
+	}
+	if len(r.Body) > maxReviewBody {
+		return validationErrorf("body must be at most %d characters", maxReviewBody)
+	}
+	return nil
+}
+
+// audienceRating returns the average score, rounded to two decimals, of
+// count reviews whose scores add up to total
+func audienceRating(total, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+	return math.Round(float64(total)*100/float64(count)) / 100
+}
@@ -0,0 +1,211 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// insertRevision snapshots a movie as its next revision. It is called with
+// the transaction of the change that replaces the snapshot, after the movie
+// row has been locked.
+func insertRevision(ctx context.Context, tx *sql.Tx, movie *Movie, action string, actor Actor) error {
+	snapshot, err := json.Marshal(movie)
+	if err != nil {
+		return err
+	}
+
+	stmt := `
+	INSERT INTO
+		movie_revisions (movie_id, revision, action, actor_id, snapshot, created_at)
+	SELECT
+		$1, coalesce(max(revision), 0) + 1, $2, $3, $4, $5
+	FROM
+		movie_revisions
+	WHERE
+		movie_id = $1
+	`
+
+	_, err = tx.ExecContext(ctx, stmt, movie.ID, action, actor.UserID, string(snapshot), time.Now())
+	return err
+}
+
+// Revisions returns the revisions of a movie, newest first, or ErrNotFound
+// if there is no such movie. Movies in the trash keep their revisions.
+func (m *DBModel) Revisions(ctx context.Context, movieID int) ([]*Revision, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	var exists bool
+	if err := m.DB.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM movies WHERE id = $1)", movieID).Scan(&exists); err != nil {
+		return nil, dbError(err)
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	query := `
+	SELECT
+		id, movie_id, revision, action, actor_id, snapshot, created_at
+	FROM
+		movie_revisions
+	WHERE
+		movie_id = $1
+	ORDER BY
+		revision DESC
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, movieID)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	var revisions []*Revision
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, dbError(err)
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, dbError(rows.Err())
+}
+
+// Revision returns one revision of a movie, or ErrRevisionNotFound
+func (m *DBModel) Revision(ctx context.Context, movieID, revision int) (*Revision, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	r, err := getRevision(ctx, m.DB, movieID, revision)
+	return r, dbError(err)
+}
+
+// RevertMovie restores a movie, including its genres, to the state kept in
+// one of its revisions. The revert only succeeds if version is still the
+// stored version of the movie; otherwise ErrEditConflict is returned. The
+// state being replaced becomes a new revision, and a movie in the trash is
+// taken out of it.
+func (m *DBModel) RevertMovie(ctx context.Context, movieID, revision, version int, actor Actor) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		before, err := getMovie(ctx, tx, movieID, true)
+		if err != nil {
+			return err
+		}
+		if before.Version != version {
+			return ErrEditConflict
+		}
+
+		target, err := getRevision(ctx, tx, movieID, revision)
+		if err != nil {
+			return err
+		}
+
+		if err = insertRevision(ctx, tx, before, ActionMovieRevert, actor); err != nil {
+			return err
+		}
+
+		movie := target.Movie
+		if movie.MovieGenre, err = existingGenres(ctx, tx, movie.MovieGenre); err != nil {
+			return err
+		}
+		movie.ID = movieID
+		movie.UpdatedAt = time.Now()
+		movie.DeletedAt = nil
+		if err = updateMovie(ctx, tx, movie); err != nil {
+			return err
+		}
+
+		after, err := getMovie(ctx, tx, movieID, false)
+		if err != nil {
+			return err
+		}
+
+		return insertAudit(ctx, tx, actor, ActionMovieRevert, "movie", movieID, before, after)
+	})
+}
+
+// existingGenres drops the genres deleted since a snapshot was taken, so
+// that reverting does not fail on them
+func existingGenres(ctx context.Context, tx *sql.Tx, genres map[int]string) (map[int]string, error) {
+	ids := make([]int64, 0, len(genres))
+	for id := range genres {
+		ids = append(ids, int64(id))
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM genres WHERE id = ANY($1)", pq.Int64Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	existing := make(map[int]string)
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		existing[id] = genres[id]
+	}
+
+	return existing, rows.Err()
+}
+
+// getRevision returns one revision of a movie, or ErrRevisionNotFound
+func getRevision(ctx context.Context, q queryer, movieID, revision int) (*Revision, error) {
+	query := `
+	SELECT
+		id, movie_id, revision, action, actor_id, snapshot, created_at
+	FROM
+		movie_revisions
+	WHERE
+		movie_id = $1 AND revision = $2
+	`
+
+	rows, err := q.QueryContext(ctx, query, movieID, revision)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, ErrRevisionNotFound
+	}
+
+	return scanRevision(rows)
+}
+
+// scanRevision reads a revision from the current row
+func scanRevision(rows *sql.Rows) (*Revision, error) {
+	var r Revision
+	var snapshot []byte
+
+	err := rows.Scan(
+		&r.ID,
+		&r.MovieID,
+		&r.Revision,
+		&r.Action,
+		&r.ActorID,
+		&snapshot,
+		&r.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(snapshot, &r.Movie); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
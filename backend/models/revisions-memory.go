@@ -0,0 +1,99 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// revise snapshots a movie as its next revision. The caller must hold m.mu
+// for writing.
+func (m *MemoryModel) revise(movie *Movie, action string, actor Actor) {
+	revisions := m.revisions[movie.ID]
+	m.revisions[movie.ID] = append(revisions, &Revision{
+		ID:        m.nextRevID,
+		MovieID:   movie.ID,
+		Revision:  len(revisions) + 1,
+		Action:    action,
+		ActorID:   actor.UserID,
+		Movie:     *movie,
+		CreatedAt: time.Now(),
+	})
+	m.nextRevID++
+}
+
+// Revisions returns the revisions of a movie, newest first, or ErrNotFound
+// if there is no such movie
+func (m *MemoryModel) Revisions(ctx context.Context, movieID int) ([]*Revision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.movies[movieID]; !ok {
+		return nil, ErrNotFound
+	}
+
+	stored := m.revisions[movieID]
+	revisions := make([]*Revision, 0, len(stored))
+	for i := len(stored) - 1; i >= 0; i-- {
+		r := *stored[i]
+		revisions = append(revisions, &r)
+	}
+
+	return revisions, nil
+}
+
+// Revision returns one revision of a movie, or ErrRevisionNotFound
+func (m *MemoryModel) Revision(ctx context.Context, movieID, revision int) (*Revision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stored := m.revisions[movieID]
+	if revision < 1 || revision > len(stored) {
+		return nil, ErrRevisionNotFound
+	}
+
+	r := *stored[revision-1]
+	return &r, nil
+}
+
+// RevertMovie restores a movie, including its genres, to the state kept in
+// one of its revisions. The revert only succeeds if version is still the
+// stored version of the movie; otherwise ErrEditConflict is returned. The
+// state being replaced becomes a new revision, and a movie in the trash is
+// taken out of it.
+func (m *MemoryModel) RevertMovie(ctx context.Context, movieID, revision, version int, actor Actor) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	movie, ok := m.movies[movieID]
+	if !ok {
+		return ErrNotFound
+	}
+	if movie.Version != version {
+		return ErrEditConflict
+	}
+
+	stored := m.revisions[movieID]
+	if revision < 1 || revision > len(stored) {
+		return ErrRevisionNotFound
+	}
+	target := stored[revision-1].Movie
+
+	// Genres deleted since the snapshot are dropped rather than failing
+	// the revert.
+	var genreIDs []int
+	for _, id := range target.GenreIDs() {
+		if _, ok := m.genres[id]; ok {
+			genreIDs = append(genreIDs, id)
+		}
+	}
+
+	before := m.copyMovie(movie)
+	m.revise(before, ActionMovieRevert, actor)
+	assignMovie(movie, target)
+	movie.UpdatedAt = time.Now()
+	movie.DeletedAt = nil
+	movie.Version++
+	m.replaceGenres(movieID, genreIDs)
+
+	return m.audit(actor, ActionMovieRevert, "movie", movieID, before, m.copyMovie(movie))
+}
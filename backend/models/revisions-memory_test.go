@@ -0,0 +1,55 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRevertMovieChecksVersion(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryModel()
+
+	id, err := m.InsertMovie(ctx, Movie{
+		Title:       "Alien",
+		Year:        1979,
+		ReleaseDate: time.Date(1979, 5, 25, 0, 0, 0, 0, time.UTC),
+	}, Actor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	movie, err := m.Get(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale := movie.Version
+
+	movie.Title = "Aliens"
+	if err = m.UpdateMovie(ctx, *movie, Actor{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = m.RevertMovie(ctx, id, 1, stale, Actor{}); !errors.Is(err, ErrEditConflict) {
+		t.Fatalf("revert from a stale version: got %v, want ErrEditConflict", err)
+	}
+
+	if err = m.RevertMovie(ctx, id, 1, stale+1, Actor{}); err != nil {
+		t.Fatal(err)
+	}
+	if movie, err = m.Get(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if movie.Title != "Alien" {
+		t.Errorf("title = %q after the revert, want Alien", movie.Title)
+	}
+}
+
+func TestRevisionsOfMissingMovie(t *testing.T) {
+	m := NewMemoryModel()
+
+	if _, err := m.Revisions(context.Background(), 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ActionMovieRevert is the audit action of reverting a movie to a revision
+const ActionMovieRevert = "movie.revert"
+
+// ErrRevisionNotFound is returned for an unknown movie revision
+var ErrRevisionNotFound = newError(ErrNotFound, "revision not found")
+
+// Revision is a snapshot of a movie, including its genres, taken just before
+// the change named by Action replaced it
+type Revision struct {
+	ID        int       `json:"id"`
+	MovieID   int       `json:"movie_id"`
+	Revision  int       `json:"revision"`
+	Action    string    `json:"action"`
+	ActorID   int       `json:"actor_id"`
+	Movie     Movie     `json:"movie"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DiffMovies returns the fields that differ between two versions of a movie
+// as {"field": {"from": old, "to": new}}
+func DiffMovies(from, to *Movie) (json.RawMessage, error) {
+	a, err := json.Marshal(from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(to)
+	if err != nil {
+		return nil, err
+	}
+	return diffJSON(a, b)
+}
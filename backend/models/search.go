@@ -0,0 +1,77 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	highlightStart = "<mark>"
+	highlightStop  = "</mark>"
+	snippetWords   = 20
+)
+
+// searchTerms splits a search query into lower-cased words, dropping
+// punctuation and any tsquery operators
+func searchTerms(q string) []string {
+	return strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// prefixQuery turns search terms into a tsquery matching every term as a
+// prefix, e.g. "star wa" becomes "star:* & wa:*"
+func prefixQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, t := range terms {
+		parts[i] = t + ":*"
+	}
+	return strings.Join(parts, " & ")
+}
+
+// highlight returns the words of text with every word that starts with one
+// of the terms wrapped in highlight markers, the set of terms that matched
+// and the index of the first matching word (-1 if none did)
+func highlight(text string, terms []string) ([]string, map[string]bool, int) {
+	words := strings.Fields(text)
+	matched := make(map[string]bool)
+	first := -1
+
+	for i, word := range words {
+		hit := false
+		for _, w := range searchTerms(word) {
+			for _, t := range terms {
+				if strings.HasPrefix(w, t) {
+					matched[t] = true
+					hit = true
+				}
+			}
+		}
+
+		if hit {
+			words[i] = highlightStart + word + highlightStop
+			if first < 0 {
+				first = i
+			}
+		}
+	}
+
+	return words, matched, first
+}
+
+// snippet returns up to snippetWords words of text around the first match
+// with the matching words highlighted
+func snippet(text string, terms []string) string {
+	words, _, first := highlight(text, terms)
+
+	start := first - snippetWords/4
+	if start < 0 {
+		start = 0
+	}
+	end := start + snippetWords
+	if end > len(words) {
+		end = len(words)
+	}
+
+	return strings.Join(words[start:end], " ")
+}
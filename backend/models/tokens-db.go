@@ -0,0 +1,149 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// InsertRefreshToken stores a new refresh token and removes the user's
+// expired ones
+func (m *DBModel) InsertRefreshToken(ctx context.Context, token RefreshToken) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE user_id = $1 AND expires_at < $2", token.UserID, time.Now()); err != nil {
+			return err
+		}
+		return insertRefreshToken(ctx, tx, token)
+	})
+}
+
+// RotateRefreshToken marks the refresh token with the given hash as used,
+// stores next in its family and returns the user it belongs to. Unknown,
+// expired and revoked tokens are rejected with ErrInvalidToken. A token that
+// was already used revokes its whole family and returns ErrTokenReuse.
+func (m *DBModel) RotateRefreshToken(ctx context.Context, hash []byte, next RefreshToken) (*User, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	var user *User
+	reused := false
+	err := m.withTx(ctx, func(tx *sql.Tx) error {
+		var current RefreshToken
+		err := tx.QueryRowContext(ctx, "SELECT id, user_id, family, expires_at, used_at, revoked_at FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE", hash).Scan(
+			&current.ID,
+			&current.UserID,
+			&current.Family,
+			&current.ExpiresAt,
+			&current.UsedAt,
+			&current.RevokedAt,
+		)
+		if err == sql.ErrNoRows {
+			return ErrInvalidToken
+		}
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if current.UsedAt != nil && current.RevokedAt == nil {
+			// commit the revocation, then report the reuse
+			reused = true
+			return revokeFamily(ctx, tx, current.Family, now)
+		}
+		if current.UsedAt != nil || current.RevokedAt != nil || !current.ExpiresAt.After(now) {
+			return ErrInvalidToken
+		}
+
+		if _, err = tx.ExecContext(ctx, "UPDATE refresh_tokens SET used_at = $1 WHERE id = $2", now, current.ID); err != nil {
+			return err
+		}
+
+		next.UserID, next.Family = current.UserID, current.Family
+		if err = insertRefreshToken(ctx, tx, next); err != nil {
+			return err
+		}
+
+		user, err = getUser(ctx, tx, current.UserID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		return nil, ErrTokenReuse
+	}
+
+	return user, nil
+}
+
+// RevokeRefreshToken revokes the family of a user's refresh token, signing
+// out the login it descends from
+func (m *DBModel) RevokeRefreshToken(ctx context.Context, userID int, hash []byte) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		var family string
+		err := tx.QueryRowContext(ctx, "SELECT family FROM refresh_tokens WHERE token_hash = $1 AND user_id = $2", hash, userID).Scan(&family)
+		if err == sql.ErrNoRows {
+			return ErrInvalidToken
+		}
+		if err != nil {
+			return err
+		}
+
+		return revokeFamily(ctx, tx, family, time.Now())
+	})
+}
+
+// DenyToken adds the ID of an access token to the denylist until the token
+// expires, and drops the entries of tokens that have expired since
+func (m *DBModel) DenyToken(ctx context.Context, jti string, expires time.Time) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM denied_tokens WHERE expires_at < $1", time.Now()); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, "INSERT INTO denied_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING", jti, expires)
+		return err
+	})
+}
+
+// TokenDenied reports whether the access token with the given ID was revoked
+func (m *DBModel) TokenDenied(ctx context.Context, jti string) (bool, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	var denied bool
+	err := m.DB.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM denied_tokens WHERE jti = $1)", jti).Scan(&denied)
+	if err != nil {
+		return false, dbError(err)
+	}
+
+	return denied, nil
+}
+
+// insertRefreshToken stores a refresh token
+func insertRefreshToken(ctx context.Context, tx *sql.Tx, token RefreshToken) error {
+	stmt := `
+	INSERT INTO
+		refresh_tokens (user_id, family, token_hash, expires_at, created_at)
+	VALUES
+		($1, $2, $3, $4, $5)
+	`
+
+	_, err := tx.ExecContext(ctx, stmt, token.UserID, token.Family, token.Hash, token.ExpiresAt, time.Now())
+	return err
+}
+
+// revokeFamily revokes every refresh token of a family that is still valid
+func revokeFamily(ctx context.Context, tx *sql.Tx, family string, at time.Time) error {
+	_, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = $1 WHERE family = $2 AND revoked_at IS NULL", at, family)
+	return err
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"time"
+)
+
+// InsertRefreshToken stores a new refresh token and removes the user's
+// expired ones
+func (m *MemoryModel) InsertRefreshToken(ctx context.Context, token RefreshToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	tokens := m.refreshTokens[:0]
+	for _, t := range m.refreshTokens {
+		if t.UserID != token.UserID || t.ExpiresAt.After(now) {
+			tokens = append(tokens, t)
+		}
+	}
+	m.refreshTokens = tokens
+
+	m.insertRefreshToken(token)
+	return nil
+}
+
+// RotateRefreshToken marks the refresh token with the given hash as used,
+// stores next in its family and returns the user it belongs to. Unknown,
+// expired and revoked tokens are rejected with ErrInvalidToken. A token that
+// was already used revokes its whole family and returns ErrTokenReuse.
+func (m *MemoryModel) RotateRefreshToken(ctx context.Context, hash []byte, next RefreshToken) (*User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current := m.findRefreshToken(hash)
+	if current == nil {
+		return nil, ErrInvalidToken
+	}
+
+	now := time.Now()
+	if current.UsedAt != nil && current.RevokedAt == nil {
+		m.revokeFamily(current.Family, now)
+		return nil, ErrTokenReuse
+	}
+	if current.UsedAt != nil || current.RevokedAt != nil || !current.ExpiresAt.After(now) {
+		return nil, ErrInvalidToken
+	}
+
+	user, ok := m.users[current.UserID]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	current.UsedAt = &now
+	next.UserID, next.Family = current.UserID, current.Family
+	m.insertRefreshToken(next)
+
+	u := *user
+	return &u, nil
+}
+
+// RevokeRefreshToken revokes the family of a user's refresh token, signing
+// out the login it descends from
+func (m *MemoryModel) RevokeRefreshToken(ctx context.Context, userID int, hash []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	token := m.findRefreshToken(hash)
+	if token == nil || token.UserID != userID {
+		return ErrInvalidToken
+	}
+
+	m.revokeFamily(token.Family, time.Now())
+	return nil
+}
+
+// DenyToken adds the ID of an access token to the denylist until the token
+// expires, and drops the entries of tokens that have expired since
+func (m *MemoryModel) DenyToken(ctx context.Context, jti string, expires time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for id, at := range m.deniedTokens {
+		if at.Before(now) {
+			delete(m.deniedTokens, id)
+		}
+	}
+
+	m.deniedTokens[jti] = expires
+	return nil
+}
+
+// TokenDenied reports whether the access token with the given ID was revoked
+func (m *MemoryModel) TokenDenied(ctx context.Context, jti string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, denied := m.deniedTokens[jti]
+	return denied, nil
+}
+
+// insertRefreshToken stores a refresh token. The caller must hold m.mu.
+func (m *MemoryModel) insertRefreshToken(token RefreshToken) {
+	token.ID = m.nextRefreshID
+	token.CreatedAt = time.Now()
+	m.nextRefreshID++
+	m.refreshTokens = append(m.refreshTokens, &token)
+}
+
+// findRefreshToken returns the refresh token with the given hash, or nil.
+// The caller must hold m.mu.
+func (m *MemoryModel) findRefreshToken(hash []byte) *RefreshToken {
+	for _, token := range m.refreshTokens {
+		if bytes.Equal(token.Hash, hash) {
+			return token
+		}
+	}
+	return nil
+}
+
+// revokeFamily revokes every refresh token of a family that is still valid.
+// The caller must hold m.mu.
+func (m *MemoryModel) revokeFamily(family string, at time.Time) {
+	for _, token := range m.refreshTokens {
+		if token.Family == family && token.RevokedAt == nil {
+			revoked := at
+			token.RevokedAt = &revoked
+		}
+	}
+}
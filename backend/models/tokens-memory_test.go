@@ -0,0 +1,84 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRotateRefreshTokenDetectsReuse(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryModel()
+
+	user, err := m.InsertUser(ctx, User{Email: "ada@example.com", Password: "hash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, stored, err := NewRefreshToken(user.ID, "", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = m.InsertRefreshToken(ctx, stored); err != nil {
+		t.Fatal(err)
+	}
+
+	// the store fills in the user and family of the next token
+	second, next, err := NewRefreshToken(0, "", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := m.RotateRefreshToken(ctx, HashSecret(first), next)
+	if err != nil {
+		t.Fatalf("first rotation: %v", err)
+	}
+	if got.ID != user.ID {
+		t.Errorf("rotation returned user %d, want %d", got.ID, user.ID)
+	}
+
+	// replaying the used token is taken as a theft and signs out the family
+	_, replay, err := NewRefreshToken(0, "", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.RotateRefreshToken(ctx, HashSecret(first), replay); !errors.Is(err, ErrTokenReuse) {
+		t.Fatalf("replaying the first token: got %v, want ErrTokenReuse", err)
+	}
+
+	_, after, err := NewRefreshToken(0, "", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.RotateRefreshToken(ctx, HashSecret(second), after); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("newest token after the replay: got %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestRotateRefreshTokenRejectsUnknownAndExpired(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryModel()
+
+	user, err := m.InsertUser(ctx, User{Email: "ada@example.com", Password: "hash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expired, stored, err := NewRefreshToken(user.ID, "", -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = m.InsertRefreshToken(ctx, stored); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, secret := range map[string]string{"unknown": "not-a-token", "expired": expired} {
+		_, next, err := NewRefreshToken(0, "", time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = m.RotateRefreshToken(ctx, HashSecret(secret), next); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s token: got %v, want ErrInvalidToken", name, err)
+		}
+	}
+}
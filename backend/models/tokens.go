@@ -0,0 +1,82 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+var (
+	// ErrInvalidToken is returned for a refresh token that is unknown,
+	// expired or revoked
+	ErrInvalidToken = newError(ErrValidation, "invalid or expired refresh token")
+	// ErrTokenReuse is returned when a refresh token is used a second time.
+	// Every token of its family is revoked before it is returned.
+	ErrTokenReuse = newError(ErrValidation, "refresh token has already been used, please log in again")
+)
+
+// RefreshToken is the stored form of a refresh token. Only the hash of the
+// token is kept; the token itself is handed to the client once.
+type RefreshToken struct {
+	ID        int
+	UserID    int
+	Family    string // shared by the tokens descending from one login
+	Hash      []byte
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// NewRefreshToken generates a refresh token for a user that expires after
+// ttl. An empty family starts a new one. It returns the token to give to
+// the client and its stored form.
+func NewRefreshToken(userID int, family string, ttl time.Duration) (string, RefreshToken, error) {
+	if family == "" {
+		b, err := randomBytes(16)
+		if err != nil {
+			return "", RefreshToken{}, err
+		}
+		family = hex.EncodeToString(b)
+	}
+
+	plain, hash, err := NewSecret()
+	if err != nil {
+		return "", RefreshToken{}, err
+	}
+
+	return plain, RefreshToken{
+		UserID:    userID,
+		Family:    family,
+		Hash:      hash,
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+// NewSecret returns a random URL-safe secret and its hash
+func NewSecret() (string, []byte, error) {
+	b, err := randomBytes(32)
+	if err != nil {
+		return "", nil, err
+	}
+
+	plain := base64.RawURLEncoding.EncodeToString(b)
+	return plain, HashSecret(plain), nil
+}
+
+// HashSecret returns the SHA-256 hash under which a secret is stored
+func HashSecret(plain string) []byte {
+	sum := sha256.Sum256([]byte(plain))
+	return sum[:]
+}
+
+// randomBytes returns n bytes from the system's secure random source
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// UserByEmail returns the user registered with an email address
+func (m *DBModel) UserByEmail(ctx context.Context, email string) (*User, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		id, email, password_hash, role, created_at, updated_at
+	FROM
+		users
+	WHERE
+		lower(email) = $1
+	`
+
+	var user User
+	err := m.DB.QueryRowContext(ctx, query, NormalizeEmail(email)).Scan(
+		&user.ID,
+		&user.Email,
+		&user.Password,
+		&user.Role,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	return &user, nil
+}
+
+// InsertUser registers a user whose password has been set with SetPassword
+// and returns it with its new ID. Users without a role are viewers. An email
+// address that is already taken is rejected with ErrDuplicateEmail.
+func (m *DBModel) InsertUser(ctx context.Context, user User) (*User, error) {
+	user.Email = NormalizeEmail(user.Email)
+	if user.Role == "" {
+		user.Role = RoleViewer
+	}
+	if err := user.Validate(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	stmt := `
+	INSERT INTO
+		users (email, password_hash, role, created_at, updated_at)
+	VALUES
+		($1, $2, $3, $4, $4)
+	RETURNING
+		id, created_at, updated_at
+	`
+
+	err := m.DB.QueryRowContext(ctx, stmt, user.Email, user.Password, user.Role, time.Now()).Scan(
+		&user.ID,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if isPQError(err, pqUniqueViolation) {
+		return nil, ErrDuplicateEmail
+	}
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	return &user, nil
+}
+
+// UpdateUserPassword replaces the password hash of a user
+func (m *DBModel) UpdateUserPassword(ctx context.Context, id int, hash string) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	return dbError(updatePassword(ctx, m.DB, id, hash, time.Now()))
+}
+
+// updatePassword replaces the password hash of a user, or returns
+// ErrNotFound. It is the only place a password hash is written.
+func updatePassword(ctx context.Context, q queryer, id int, hash string, at time.Time) error {
+	result, err := q.ExecContext(ctx, "UPDATE users SET password_hash = $1, updated_at = $2 WHERE id = $3", hash, at, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// UpdateUserRole changes the role of a user
+func (m *DBModel) UpdateUserRole(ctx context.Context, id int, role string, actor Actor) error {
+	if _, ok := roleRanks[role]; !ok {
+		return ErrInvalidRole
+	}
+
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		var previous string
+		err := tx.QueryRowContext(ctx, "SELECT role FROM users WHERE id = $1 FOR UPDATE", id).Scan(&previous)
+		if err != nil {
+			return err
+		}
+
+		if _, err = tx.ExecContext(ctx, "UPDATE users SET role = $1, updated_at = $2 WHERE id = $3", role, time.Now(), id); err != nil {
+			return err
+		}
+
+		return insertAudit(ctx, tx, actor, ActionUserRole, "user", id, map[string]string{"role": previous}, map[string]string{"role": role})
+	})
+}
+
+// getUser returns the user with the given ID
+func getUser(ctx context.Context, q queryer, id int) (*User, error) {
+	query := `
+	SELECT
+		id, email, password_hash, role, created_at, updated_at
+	FROM
+		users
+	WHERE
+		id = $1
+	`
+
+	var user User
+	err := q.QueryRowContext(ctx, query, id).Scan(
+		&user.ID,
+		&user.Email,
+		&user.Password,
+		&user.Role,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
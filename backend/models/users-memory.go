@@ -0,0 +1,98 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// UserByEmail returns the user registered with an email address
+func (m *MemoryModel) UserByEmail(ctx context.Context, email string) (*User, error) {
+	email = NormalizeEmail(email)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, user := range m.users {
+		if user.Email == email {
+			u := *user
+			return &u, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// InsertUser registers a user whose password has been set with SetPassword
+// and returns it with its new ID. Users without a role are viewers. An email
+// address that is already taken is rejected with ErrDuplicateEmail.
+func (m *MemoryModel) InsertUser(ctx context.Context, user User) (*User, error) {
+	user.Email = NormalizeEmail(user.Email)
+	if user.Role == "" {
+		user.Role = RoleViewer
+	}
+	if err := user.Validate(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.users {
+		if existing.Email == user.Email {
+			return nil, ErrDuplicateEmail
+		}
+	}
+
+	now := time.Now()
+	user.ID = m.nextUserID
+	user.CreatedAt, user.UpdatedAt = now, now
+	m.nextUserID++
+	m.users[user.ID] = &user
+
+	u := user
+	return &u, nil
+}
+
+// UpdateUserPassword replaces the password hash of a user
+func (m *MemoryModel) UpdateUserPassword(ctx context.Context, id int, hash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.setPassword(id, hash, time.Now())
+}
+
+// setPassword replaces the password hash of a user, or returns ErrNotFound.
+// It is the only place a password hash is written. The caller must hold m.mu
+// for writing.
+func (m *MemoryModel) setPassword(id int, hash string, at time.Time) error {
+	user, ok := m.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	user.Password = hash
+	user.UpdatedAt = at
+
+	return nil
+}
+
+// UpdateUserRole changes the role of a user
+func (m *MemoryModel) UpdateUserRole(ctx context.Context, id int, role string, actor Actor) error {
+	if _, ok := roleRanks[role]; !ok {
+		return ErrInvalidRole
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	previous := user.Role
+	user.Role = role
+	user.UpdatedAt = time.Now()
+
+	return m.audit(actor, ActionUserRole, "user", id, map[string]string{"role": previous}, map[string]string{"role": role})
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestUpdateUserPassword(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryModel()
+
+	if err := m.UpdateUserPassword(ctx, 1, "hash"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("updating a missing user: got %v, want ErrNotFound", err)
+	}
+
+	// the store keeps hashes as they are given, so real ones are not needed
+	saved, err := m.InsertUser(ctx, User{Email: "ada@example.com", Password: "old-hash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = m.UpdateUserPassword(ctx, saved.ID, "new-hash"); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := m.UserByEmail(ctx, "ada@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Password != "new-hash" {
+		t.Errorf("password hash = %q, want new-hash", stored.Password)
+	}
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password limits. bcrypt ignores everything after the first 72 bytes.
+const (
+	MinPasswordLength = 8
+	maxPasswordBytes  = 72
+	maxEmailLength    = 255
+	passwordCost      = 12
+)
+
+// Roles, from least to most privileged. Every role may do what the roles
+// before it may.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// ActionUserRole is the audit action of changing a user's role
+const ActionUserRole = "user.role"
+
+var roleRanks = map[string]int{RoleViewer: 1, RoleEditor: 2, RoleAdmin: 3}
+
+var (
+	// ErrDuplicateEmail is returned when an email address is already registered
+	ErrDuplicateEmail = newError(ErrConflict, "an account with that email already exists")
+	// ErrInvalidRole is returned for a role other than viewer, editor and admin
+	ErrInvalidRole = newError(ErrValidation, "role must be viewer, editor or admin")
+)
+
+// HasRole reports whether a role grants at least the required role. Unknown
+// roles grant nothing.
+func HasRole(role, required string) bool {
+	rank, ok := roleRanks[role]
+	return ok && rank >= roleRanks[required]
+}
+
+// User is a registered account. Password holds the bcrypt hash of the
+// password and is never written to JSON.
+type User struct {
+	ID        int       `json:"id"`
+	Email     string    `json:"email"`
+	Password  string    `json:"-"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"-"`
+}
+
+// NormalizeEmail returns an email address in the form it is stored in
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Validate checks the email address and role of a user
+func (u *User) Validate() error {
+	if _, ok := roleRanks[u.Role]; !ok {
+		return ErrInvalidRole
+	}
+	at := strings.LastIndex(u.Email, "@")
+	if at < 1 || at == len(u.Email)-1 || strings.ContainsAny(u.Email, " \t\r\n") {
+		return validationErrorf("email must be a valid email address")
+	}
+	if len(u.Email) > maxEmailLength {
+		return validationErrorf("email must be at most %d characters", maxEmailLength)
+	}
+	return nil
+}
+
+// SetPassword checks the strength of a plain text password and stores its hash
+func (u *User) SetPassword(password string) error {
+	if err := u.checkPassword(password); err != nil {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordCost)
+	if err != nil {
+		return err
+	}
+
+	u.Password = string(hash)
+	return nil
+}
+
+// PasswordMatches reports whether a plain text password matches the stored hash
+func (u *User) PasswordMatches(password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// checkPassword rejects passwords that are too short, too long for bcrypt,
+// made of a single kind of character or containing the user's email name
+func (u *User) checkPassword(password string) error {
+	if len([]rune(password)) < MinPasswordLength {
+		return validationErrorf("password must be at least %d characters", MinPasswordLength)
+	}
+	if len(password) > maxPasswordBytes {
+		return validationErrorf("password must be at most %d bytes", maxPasswordBytes)
+	}
+
+	var letter, other bool
+	for _, r := range password {
+		if unicode.IsLetter(r) {
+			letter = true
+		} else {
+			other = true
+		}
+	}
+	if !letter || !other {
+		return validationErrorf("password must contain letters and at least one digit or symbol")
+	}
+
+	// short names such as "me" would reject too many passwords
+	email := NormalizeEmail(u.Email)
+	if at := strings.LastIndex(email, "@"); at >= 3 && strings.Contains(strings.ToLower(password), email[:at]) {
+		return validationErrorf("password must not contain your email address")
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package models
+
+import "testing"
+
+func TestHasRole(t *testing.T) {
+	tests := []struct {
+		role, required string
+		want           bool
+	}{
+		{RoleViewer, RoleViewer, true},
+		{RoleViewer, RoleEditor, false},
+		{RoleViewer, RoleAdmin, false},
+		{RoleEditor, RoleViewer, true},
+		{RoleEditor, RoleEditor, true},
+		{RoleEditor, RoleAdmin, false},
+		{RoleAdmin, RoleViewer, true},
+		{RoleAdmin, RoleEditor, true},
+		{RoleAdmin, RoleAdmin, true},
+		// unknown roles, such as from a forged or outdated claim, grant nothing
+		{"", RoleViewer, false},
+		{"superuser", RoleViewer, false},
+		{"Admin", RoleAdmin, false},
+		{" admin", RoleAdmin, false},
+	}
+
+	for _, tt := range tests {
+		if got := HasRole(tt.role, tt.required); got != tt.want {
+			t.Errorf("HasRole(%q, %q) = %v, want %v", tt.role, tt.required, got, tt.want)
+		}
+	}
+}